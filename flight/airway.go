@@ -0,0 +1,131 @@
+package flight
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	yaml "github.com/goccy/go-yaml"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
+)
+
+const airwayVersion = "v1alpha1"
+
+type AirwayConfig struct {
+	Group   string
+	Kind    string
+	WasmURL string
+}
+
+// BuildAirway returns the yoke `Airway` defining a custom resource with the values as its `spec`, so the Flight can be
+// run by the ATC for every such resource
+func BuildAirway(config AirwayConfig) (unstructured.Unstructured, error) {
+	if config.Group == "" || config.Kind == "" || config.WasmURL == "" {
+		return unstructured.Unstructured{}, fmt.Errorf("group, kind and wasm URL are required")
+	}
+	plural := strings.ToLower(config.Kind) + "s"
+	spec := schema.OpenAPISchema()
+	crd := apiextensionsv1.CustomResourceDefinitionSpec{
+		Group: config.Group,
+		Names: apiextensionsv1.CustomResourceDefinitionNames{
+			Kind:     config.Kind,
+			ListKind: config.Kind + "List",
+			Plural:   plural,
+			Singular: strings.ToLower(config.Kind),
+		},
+		Scope: apiextensionsv1.NamespaceScoped,
+		Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+			{
+				Name:    airwayVersion,
+				Served:  true,
+				Storage: true,
+				Schema: &apiextensionsv1.CustomResourceValidation{
+					OpenAPIV3Schema: &apiextensionsv1.JSONSchemaProps{
+						Type: "object",
+						Properties: map[string]apiextensionsv1.JSONSchemaProps{
+							"spec": spec,
+							"status": {
+								Type:                   "object",
+								XPreserveUnknownFields: ptr.To(true),
+							},
+						},
+						Required: []string{"spec"},
+					},
+				},
+				Subresources: &apiextensionsv1.CustomResourceSubresources{
+					Status: &apiextensionsv1.CustomResourceSubresourceStatus{},
+				},
+			},
+		},
+	}
+	template, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&crd)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "yoke.cd/v1alpha1",
+		"kind":       "Airway",
+		"metadata": map[string]interface{}{
+			"name": fmt.Sprintf("%s.%s", plural, config.Group),
+		},
+		"spec": map[string]interface{}{
+			"wasmUrls": map[string]interface{}{
+				"flight": config.WasmURL,
+			},
+			"template": template,
+		},
+	}}, nil
+}
+
+type customResource struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Metadata   struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"metadata"`
+	Spec map[string]interface{} `json:"spec"`
+}
+
+// unwrapCustomResource returns the `spec` of the custom resource the ATC passes to the Flight, or the source as it is
+// if it's plain values
+func unwrapCustomResource(source []byte) ([]byte, *customResource, error) {
+	var cr customResource
+	// plain values don't have to be a valid custom resource, e.g. SOPS-encrypted ones
+	if err := yaml.Unmarshal(source, &cr); err != nil || cr.APIVersion == "" || cr.Kind == "" || cr.Spec == nil {
+		return source, nil, nil
+	}
+	spec, err := json.Marshal(cr.Spec)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error while reading spec of %s '%s': %v", cr.Kind, cr.Metadata.Name, err)
+	}
+	return spec, &cr, nil
+}
+
+// customResourceStatus returns the custom resource with only the status set, reporting the created objects
+func customResourceStatus(cr customResource, outputs resources.Outputs) (unstructured.Unstructured, error) {
+	bytes, err := json.Marshal(outputs)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+	var status map[string]interface{}
+	if err := json.Unmarshal(bytes, &status); err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	u := unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"outputs": status,
+		},
+	}}
+	u.SetAPIVersion(cr.APIVersion)
+	u.SetKind(cr.Kind)
+	u.SetName(cr.Metadata.Name)
+	u.SetNamespace(cr.Metadata.Namespace)
+	return u, nil
+}
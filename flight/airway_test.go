@@ -0,0 +1,113 @@
+package flight
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	structuralschema "k8s.io/apiextensions-apiserver/pkg/apiserver/schema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestAirway(t *testing.T) {
+	t.Run("builds an Airway with a structural schema", func(t *testing.T) {
+		airway, err := BuildAirway(AirwayConfig{Group: "example.com", Kind: "Backend", WasmURL: "https://example.com/flight.wasm"})
+		require.NoError(t, err)
+		assert.Equal(t, "backends.example.com", airway.GetName())
+
+		url, _, _ := unstructured.NestedString(airway.Object, "spec", "wasmUrls", "flight")
+		assert.Equal(t, "https://example.com/flight.wasm", url)
+
+		template, _, _ := unstructured.NestedMap(airway.Object, "spec", "template")
+		var crd apiextensionsv1.CustomResourceDefinitionSpec
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(template, &crd))
+		assert.Equal(t, "example.com", crd.Group)
+		assert.Equal(t, "backends", crd.Names.Plural)
+		require.Len(t, crd.Versions, 1)
+		assert.NotNil(t, crd.Versions[0].Subresources.Status)
+
+		var internal apiextensions.JSONSchemaProps
+		require.NoError(t, apiextensionsv1.Convert_v1_JSONSchemaProps_To_apiextensions_JSONSchemaProps(crd.Versions[0].Schema.OpenAPIV3Schema, &internal, nil))
+		structural, err := structuralschema.NewStructural(&internal)
+		require.NoError(t, err)
+		assert.Empty(t, structuralschema.ValidateStructural(nil, structural))
+
+		spec := crd.Versions[0].Schema.OpenAPIV3Schema.Properties["spec"]
+		assert.NotContains(t, spec.Required, "namespace")
+		assert.Contains(t, spec.Required, "service")
+		volume := spec.Properties["volumes"].AdditionalProperties.Schema
+		assert.NotEmpty(t, volume.AnyOf)
+		assert.Contains(t, volume.Properties, "pvcName")
+		assert.True(t, *spec.Properties["podSpec"].XPreserveUnknownFields)
+	})
+
+	t.Run("fails without the wasm URL", func(t *testing.T) {
+		_, err := BuildAirway(AirwayConfig{Group: "example.com", Kind: "Backend"})
+		assert.ErrorContains(t, err, "wasm URL")
+	})
+
+	t.Run("reads values from the custom resource", func(t *testing.T) {
+		source := []byte(`{
+			"apiVersion": "example.com/v1alpha1",
+			"kind": "Backend",
+			"metadata": {"name": "payments", "namespace": "team-ns"},
+			"spec": {
+				"service": "payments",
+				"component": "api",
+				"environment": "test",
+				"image": {"repository": "payments", "tag": "1.0.0"},
+				"volumes": {"data": {"type": "tmpfs", "mounts": {"main": [{"containerPath": "/data"}]}}}
+			}
+		}`)
+		spec, cr, err := unwrapCustomResource(source)
+		require.NoError(t, err)
+		require.NotNil(t, cr)
+		assert.Equal(t, "payments", cr.Metadata.Name)
+
+		values, _, err := parseFromSource(bytes.NewReader(spec), schema.Release{Namespace: cr.Metadata.Namespace}, false)
+		require.NoError(t, err)
+		assert.Equal(t, "team-ns", values.Namespace)
+		assert.Equal(t, "payments", values.Service)
+		assert.Equal(t, schema.VolumeTypeStandardTmpfs, values.Volumes["data"].Type)
+	})
+
+	t.Run("keeps plain values as they are", func(t *testing.T) {
+		source := []byte(dedent.Dedent(`
+			service: payments
+			kind: StatefulSet
+		`))
+		spec, cr, err := unwrapCustomResource(source)
+		require.NoError(t, err)
+		assert.Nil(t, cr)
+		assert.Equal(t, source, spec)
+	})
+
+	t.Run("reports the outputs in the status", func(t *testing.T) {
+		cr := customResource{APIVersion: "example.com/v1alpha1", Kind: "Backend"}
+		cr.Metadata.Name = "payments"
+		cr.Metadata.Namespace = "team-ns"
+		outputs := resources.Outputs{
+			Service:    &resources.Ref{Name: "payments--api--test", Namespace: "team-ns", Kind: "Service"},
+			ConfigMaps: map[string]resources.Ref{},
+		}
+
+		status, err := customResourceStatus(cr, outputs)
+		require.NoError(t, err)
+		assert.Equal(t, "Backend", status.GetKind())
+		assert.Equal(t, "payments", status.GetName())
+		assert.Equal(t, "team-ns", status.GetNamespace())
+		assert.NotContains(t, status.Object, "spec")
+		name, _, _ := unstructured.NestedString(status.Object, "status", "outputs", "service", "name")
+		assert.Equal(t, "payments--api--test", name)
+		// objects which weren't created aren't reported at all
+		assert.NotContains(t, status.Object["status"].(map[string]interface{})["outputs"], "workload")
+		assert.NotContains(t, status.Object["status"].(map[string]interface{})["outputs"], "configMaps")
+	})
+}
@@ -0,0 +1,128 @@
+package flight
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapHeavyValues returns values with every map-keyed part of the chart filled with the given keys - JSON is valid YAML
+func mapHeavyValues(keys []string) []byte {
+	object := func(f func(key string) interface{}) map[string]interface{} {
+		m := map[string]interface{}{}
+		for _, key := range keys {
+			m[key] = f(key)
+		}
+		return m
+	}
+	values := map[string]interface{}{
+		"namespace":   "foo",
+		"service":     "foo",
+		"component":   "bar",
+		"environment": "test",
+		"image":       map[string]interface{}{"repository": "foo", "tag": "1.0.0"},
+		"ports":       []interface{}{map[string]interface{}{"port": 8080}},
+		"envs":        object(func(key string) interface{} { return key }),
+		"labels":      object(func(key string) interface{} { return key }),
+		"configMaps": object(func(key string) interface{} {
+			return map[string]interface{}{"a": key, "b": key}
+		}),
+		"secrets": object(func(key string) interface{} {
+			return map[string]interface{}{"password": key}
+		}),
+		"networkPolicies": object(func(key string) interface{} {
+			return map[string]interface{}{"podSelector": map[string]interface{}{}}
+		}),
+		"httpRoutes": object(func(key string) interface{} {
+			return map[string]interface{}{
+				"parentRefs": []interface{}{map[string]interface{}{"name": "gateway"}},
+				"hostnames":  []interface{}{key + ".example.com"},
+			}
+		}),
+		"volumes": object(func(key string) interface{} {
+			return map[string]interface{}{
+				"type":   "tmpfs",
+				"mounts": map[string]interface{}{"main": map[string]interface{}{"containerPath": "/" + key}},
+			}
+		}),
+		"db": map[string]interface{}{
+			"enabled":      true,
+			"clusterName":  "pg",
+			"replicas":     1,
+			"version":      15,
+			"size":         "1Gi",
+			"storageClass": "standard",
+			"users":        object(func(key string) interface{} { return []interface{}{} }),
+			"databases":    object(func(key string) interface{} { return key }),
+		},
+		"extraManifests": []interface{}{
+			map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "ConfigMap",
+				"metadata":   map[string]interface{}{"name": "extra"},
+				"data": object(func(key string) interface{} {
+					return "{{ .Values.Metadata.Service }}-" + key
+				}),
+			},
+		},
+	}
+	bytes, err := json.Marshal(values)
+	if err != nil {
+		panic(err)
+	}
+	return bytes
+}
+
+// renders the values several times, Go randomizes the order of map iteration on every range
+func assertDeterministic(t *testing.T, values []byte, renders int) {
+	t.Helper()
+	for _, encoding := range []Encoding{EncodingJSON, EncodingYAML} {
+		first, firstErr := Render(context.Background(), values, Options{Encoding: encoding})
+		for range renders {
+			result, err := Render(context.Background(), values, Options{Encoding: encoding})
+			if firstErr != nil {
+				require.EqualError(t, err, firstErr.Error())
+				continue
+			}
+			require.NoError(t, err)
+			require.Equal(t, string(first.Encoded), string(result.Encoded))
+			require.Equal(t, first.Warnings, result.Warnings)
+		}
+	}
+}
+
+func TestRenderIsDeterministic(t *testing.T) {
+	keys := []string{}
+	for i := range 20 {
+		keys = append(keys, fmt.Sprintf("key%d", i))
+	}
+	values := mapHeavyValues(keys)
+
+	result, err := Render(context.Background(), values, Options{})
+	require.NoError(t, err)
+	assert.Len(t, result.Outputs.ConfigMaps, len(keys))
+	assert.Len(t, result.Outputs.HTTPRoutes, len(keys))
+	assert.Len(t, result.Outputs.NetworkPolicies, len(keys))
+
+	assertDeterministic(t, values, 10)
+}
+
+func FuzzRenderIsDeterministic(f *testing.F) {
+	f.Add([]byte("abcdefghij"))
+	f.Add([]byte{0, 1, 2, 3, 255, 128, 64})
+	f.Add([]byte("zzzzaaaa"))
+
+	f.Fuzz(func(t *testing.T, seed []byte) {
+		// every byte is a key, duplicates collapse into one
+		keys := []string{}
+		for _, b := range seed {
+			keys = append(keys, fmt.Sprintf("k%02x", b))
+		}
+		// fewer renders than the test above, the fuzzer makes up for it by the number of inputs
+		assertDeterministic(t, mapHeavyValues(keys), 2)
+	})
+}
@@ -0,0 +1,168 @@
+package flight
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type Change string
+
+const (
+	ChangeAdded   Change = "added"
+	ChangeRemoved Change = "removed"
+	ChangeChanged Change = "changed"
+)
+
+// ObjectDiff is a difference between two renders of the same object, identified by its GVK, namespace and name
+type ObjectDiff struct {
+	Key    string
+	Change Change
+	// unified diff of the object's YAML, only for changed objects
+	Diff string
+}
+
+// DiffObjects compares two renders, the differences are sorted by the objects' keys
+func DiffObjects(old, new []unstructured.Unstructured) ([]ObjectDiff, error) {
+	oldObjects, err := objectsByKey(old)
+	if err != nil {
+		return nil, err
+	}
+	newObjects, err := objectsByKey(new)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := slices.Sorted(maps.Keys(oldObjects))
+	for key := range newObjects {
+		if _, ok := oldObjects[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	diffs := []ObjectDiff{}
+	for _, key := range keys {
+		oldObject, inOld := oldObjects[key]
+		newObject, inNew := newObjects[key]
+		switch {
+		case !inOld:
+			diffs = append(diffs, ObjectDiff{Key: key, Change: ChangeAdded})
+		case !inNew:
+			diffs = append(diffs, ObjectDiff{Key: key, Change: ChangeRemoved})
+		default:
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(oldObject)),
+				B:        difflib.SplitLines(string(newObject)),
+				FromFile: "old/" + key,
+				ToFile:   "new/" + key,
+				Context:  3,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("error while comparing %s: %v", key, err)
+			}
+			if diff != "" {
+				diffs = append(diffs, ObjectDiff{Key: key, Change: ChangeChanged, Diff: diff})
+			}
+		}
+	}
+	return diffs, nil
+}
+
+// objectsByKey returns the YAML of the objects, YAML keeps the fields sorted so the same object always encodes the same
+func objectsByKey(objects []unstructured.Unstructured) (map[string][]byte, error) {
+	byKey := map[string][]byte{}
+	for _, object := range objects {
+		key := objectKey(object)
+		if _, ok := byKey[key]; ok {
+			return nil, fmt.Errorf("%s is rendered more than once", key)
+		}
+		if object.GetAPIVersion() == "v1" && object.GetKind() == "Secret" {
+			object = redactSecret(object)
+		}
+		document, err := encodeYAML(object)
+		if err != nil {
+			return nil, err
+		}
+		byKey[key] = document
+	}
+	return byKey, nil
+}
+
+// keys the hashes of Secret values, random so the hashes of short values can't be looked up - only comparable within
+// the same run
+var redactionKey = func() []byte {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		panic(fmt.Sprintf("error while generating redaction key: %v", err))
+	}
+	return key
+}()
+
+// redactSecret replaces the values of the Secret with their hashes, so the diff shows which keys changed without
+// printing them to the CI logs - `secrets` can come decrypted from SOPS
+func redactSecret(object unstructured.Unstructured) unstructured.Unstructured {
+	redacted := *object.DeepCopy()
+	for _, field := range []string{"data", "stringData"} {
+		data, ok := redacted.Object[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key, value := range data {
+			mac := hmac.New(sha256.New, redactionKey)
+			fmt.Fprint(mac, value)
+			data[key] = fmt.Sprintf("(redacted, hmac %s)", hex.EncodeToString(mac.Sum(nil))[:16])
+		}
+	}
+	return redacted
+}
+
+func objectKey(object unstructured.Unstructured) string {
+	name := object.GetName()
+	if namespace := object.GetNamespace(); namespace != "" {
+		name = namespace + "/" + name
+	}
+	return fmt.Sprintf("%s %s %s", object.GetAPIVersion(), object.GetKind(), name)
+}
+
+// FormatDiff formats the differences for humans - `+`/`-`/`~` for added/removed/changed objects, followed by the diff
+// of the changed ones
+func FormatDiff(diffs []ObjectDiff) string {
+	var b strings.Builder
+	counts := map[Change]int{}
+	symbols := map[Change]string{ChangeAdded: "+", ChangeRemoved: "-", ChangeChanged: "~"}
+	for _, diff := range diffs {
+		counts[diff.Change]++
+		fmt.Fprintf(&b, "%s %s\n", symbols[diff.Change], diff.Key)
+		b.WriteString(diff.Diff)
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed\n", counts[ChangeAdded], counts[ChangeRemoved], counts[ChangeChanged])
+	return b.String()
+}
+
+// DecodeJSON decodes objects encoded with `EncodingJSON`, e.g. a saved render
+func DecodeJSON(encoded []byte) ([]unstructured.Unstructured, error) {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(encoded, &raw); err != nil {
+		return nil, fmt.Errorf("error while decoding objects: %v", err)
+	}
+	objects := make([]unstructured.Unstructured, 0, len(raw))
+	for i, r := range raw {
+		// unlike `encoding/json`, keeps integers as integers
+		var object unstructured.Unstructured
+		if err := object.UnmarshalJSON(r); err != nil {
+			return nil, fmt.Errorf("error while decoding object %d: %v", i, err)
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
@@ -0,0 +1,107 @@
+package flight
+
+import (
+	"context"
+	"encoding/base64"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiff(t *testing.T) {
+	render := func(t *testing.T, values string) Result {
+		t.Helper()
+		result, err := Render(context.Background(), []byte(dedent.Dedent(values)), Options{Encoding: EncodingJSON})
+		require.NoError(t, err)
+		return result
+	}
+	old := render(t, `
+		namespace: foo
+		service: foo
+		component: bar
+		environment: test
+		image:
+		  repository: foo
+		  tag: "1.0.0"
+		ports:
+		  - port: 8080
+		configMaps:
+		  old:
+		    key: value
+	`)
+	new := render(t, `
+		namespace: foo
+		service: foo
+		component: bar
+		environment: test
+		image:
+		  repository: foo
+		  tag: "1.0.0"
+		ports:
+		  - port: 8080
+		replicaCount: 3
+		configMaps:
+		  new:
+		    key: value
+	`)
+
+	t.Run("reports added, removed and changed objects", func(t *testing.T) {
+		diffs, err := DiffObjects(old.Objects, new.Objects)
+		require.NoError(t, err)
+		require.Len(t, diffs, 3)
+
+		assert.Equal(t, ObjectDiff{Key: "apps/v1 Deployment foo/foo--bar--test", Change: ChangeChanged, Diff: diffs[0].Diff}, diffs[0])
+		assert.Contains(t, diffs[0].Diff, "-  replicas: 1\n+  replicas: 3\n")
+		assert.Equal(t, ObjectDiff{Key: "v1 ConfigMap foo/foo--bar--test-new", Change: ChangeAdded}, diffs[1])
+		assert.Equal(t, ObjectDiff{Key: "v1 ConfigMap foo/foo--bar--test-old", Change: ChangeRemoved}, diffs[2])
+
+		assert.Contains(t, FormatDiff(diffs), "1 added, 1 removed, 1 changed\n")
+	})
+
+	t.Run("doesn't print the values of Secrets", func(t *testing.T) {
+		secrets := func(password string) []unstructured.Unstructured {
+			return render(t, `
+				namespace: foo
+				service: foo
+				component: bar
+				environment: test
+				image:
+				  repository: foo
+				  tag: "1.0.0"
+				ports:
+				  - port: 8080
+				secrets:
+				  db:
+				    user: admin
+				    password: `+password+`
+			`).Objects
+		}
+		diffs, err := DiffObjects(secrets("old-password"), secrets("new-password"))
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		assert.Equal(t, "v1 Secret foo/foo--bar--test-db", diffs[0].Key)
+
+		lines := strings.Split(diffs[0].Diff, "\n")
+		assert.Len(t, slices.DeleteFunc(slices.Clone(lines), func(l string) bool { return !strings.HasPrefix(l, "-  ") }), 1)
+		assert.Len(t, slices.DeleteFunc(slices.Clone(lines), func(l string) bool { return !strings.HasPrefix(l, "+  ") }), 1)
+		assert.Contains(t, diffs[0].Diff, "-  password: (redacted, hmac ")
+		assert.Contains(t, diffs[0].Diff, "+  password: (redacted, hmac ")
+		for _, plaintext := range []string{"old-password", "new-password", "admin"} {
+			assert.NotContains(t, diffs[0].Diff, plaintext)
+			assert.NotContains(t, diffs[0].Diff, base64.StdEncoding.EncodeToString([]byte(plaintext)))
+		}
+	})
+
+	t.Run("compares to a saved render", func(t *testing.T) {
+		saved, err := DecodeJSON(old.Encoded)
+		require.NoError(t, err)
+		diffs, err := DiffObjects(saved, old.Objects)
+		require.NoError(t, err)
+		assert.Empty(t, diffs)
+	})
+}
@@ -0,0 +1,35 @@
+package flight
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+)
+
+// FormatOverrides formats the fields set by the overrides for humans, one `{override}.{field}: {chart's value} -> {value}`
+// per line, in the order they were merged
+func FormatOverrides(overrides []resources.Override) string {
+	var b strings.Builder
+	replaced := 0
+	for _, override := range overrides {
+		if override.Default != nil {
+			replaced++
+		}
+		fmt.Fprintf(&b, "%s.%s: %s -> %s\n", override.Path, override.Field, formatValue(override.Default), formatValue(override.Value))
+	}
+	fmt.Fprintf(&b, "%d fields set by overrides, %d of them replaced the chart's value\n", len(overrides), replaced)
+	return b.String()
+}
+
+func formatValue(value interface{}) string {
+	if value == nil {
+		return "unset"
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(encoded)
+}
@@ -0,0 +1,128 @@
+package flight
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderExplain(t *testing.T) {
+	values := []byte(dedent.Dedent(`
+		namespace: foo
+		service: foo
+		component: bar
+		environment: test
+
+		image:
+		  repository: foo
+		  tag: "1.0.0"
+		ports:
+		  - port: 8080
+		envs:
+		  FOO: bar
+		containerSpec:
+		  imagePullPolicy: Always
+		volumes:
+		  data:
+		    type: tmpfs
+		    mounts:
+		      main:
+		        - containerPath: /data
+		sidecars:
+		  proxy:
+		    image:
+		      repository: proxy
+		      inheritMainContainerTag: true
+		    ports:
+		      - port: 9090
+		cronjobs:
+		  - name: first
+		    schedule: "0 * * * *"
+		    image:
+		      repository: foo
+		      inheritMainContainerTag: true
+		  - name: second
+		    schedule: "0 * * * *"
+		    image:
+		      repository: foo
+		      tag: "2.0.0"
+		    jobSpec:
+		      backoffLimit: 2
+		    cronJobSpec:
+		      concurrencyPolicy: Forbid
+		configMaps:
+		  settings:
+		    key: value
+		extraManifests:
+		  - apiVersion: v1
+		    kind: ConfigMap
+		    metadata:
+		      name: extra
+	`))
+
+	t.Run("annotates the objects with their values paths", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{Explain: true})
+		require.NoError(t, err)
+
+		paths := map[string]string{}
+		for _, object := range result.Objects {
+			paths[object.GetKind()+"/"+object.GetName()] = object.GetAnnotations()[resources.ValuesPathsAnnotation]
+		}
+		assert.Equal(t, "containerSpec,envs,image,ports,sidecars.proxy,volumes.data", paths["Deployment/foo--bar--test"])
+		assert.Equal(t, "ports,sidecars.proxy.ports", paths["Service/foo--bar--test"])
+		assert.Equal(t, "cronjobs[0],image.tag", paths["CronJob/first--test"])
+		assert.Equal(t, "cronjobs[1]", paths["CronJob/second--test"])
+		assert.Equal(t, "configMaps.settings", paths["ConfigMap/foo--bar--test-settings"])
+		assert.Equal(t, "extraManifests[0]", paths["ConfigMap/extra"])
+	})
+
+	t.Run("reports the fields set by overrides", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{Explain: true})
+		require.NoError(t, err)
+		assert.Equal(t, []resources.Override{
+			{Path: "containerSpec", Field: "imagePullPolicy", Default: "IfNotPresent", Value: "Always"},
+			{Path: "cronjobs[1].jobSpec", Field: "backoffLimit", Value: int64(2)},
+			{Path: "cronjobs[1].cronJobSpec", Field: "concurrencyPolicy", Default: "Allow", Value: "Forbid"},
+		}, result.Overrides)
+		assert.Equal(t, dedent.Dedent(`
+			containerSpec.imagePullPolicy: "IfNotPresent" -> "Always"
+			cronjobs[1].jobSpec.backoffLimit: unset -> 2
+			cronjobs[1].cronJobSpec.concurrencyPolicy: "Allow" -> "Forbid"
+			3 fields set by overrides, 2 of them replaced the chart's value
+		`)[1:], FormatOverrides(result.Overrides))
+	})
+
+	t.Run("doesn't explain by default", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{})
+		require.NoError(t, err)
+		assert.Nil(t, result.Overrides)
+		for _, object := range result.Objects {
+			assert.NotContains(t, object.GetAnnotations(), resources.ValuesPathsAnnotation, object.GetKind())
+		}
+	})
+
+	t.Run("only annotates paths which exist in the values", func(t *testing.T) {
+		properties := schema.OpenAPISchema().Properties
+		topLevel := regexp.MustCompile(`^[^.\[]+`)
+		for _, v := range [][]byte{values, mapHeavyValues([]string{"a", "b"})} {
+			result, err := Render(context.Background(), v, Options{Explain: true})
+			require.NoError(t, err)
+			for _, object := range result.Objects {
+				annotation, ok := object.GetAnnotations()[resources.ValuesPathsAnnotation]
+				if !ok {
+					continue
+				}
+				for _, path := range strings.Split(annotation, ",") {
+					assert.Contains(t, properties, topLevel.FindString(path), "%s: %s", objectKey(object), path)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package flight
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+)
+
+// ExtensionCreator creates the resources of an extension. `config` is the extension's block in `extensions` (nil if not
+// set), `outputs` references the resources rendered before it - the chart's own and those of the extensions it
+// depends on
+type ExtensionCreator func(values resources.DeploymentValues, config map[string]interface{}, outputs resources.Outputs) ([]resources.NamedResource, error)
+
+// Extension adds the organization's own resources to the Flight, e.g. a Backstage `Component` for every service
+type Extension struct {
+	// category of the created resources, their key in `Outputs.Custom` and the key of the extension's config in
+	// `extensions`
+	Category resources.ResourceCategory
+	// extensions rendered before this one
+	DependsOn []resources.ResourceCategory
+	Create    ExtensionCreator
+}
+
+// sortExtensions orders the extensions so that each comes after the extensions it depends on, otherwise they keep
+// their order
+func sortExtensions(extensions []Extension) ([]Extension, error) {
+	byCategory := map[resources.ResourceCategory]Extension{}
+	for _, extension := range extensions {
+		if extension.Category == "" {
+			return nil, fmt.Errorf("extension category is required")
+		}
+		if resources.IsBuiltinCategory(extension.Category) {
+			return nil, fmt.Errorf("extension '%s': category is used by the chart's own resources", extension.Category)
+		}
+		if extension.Create == nil {
+			return nil, fmt.Errorf("extension '%s': create function is required", extension.Category)
+		}
+		if _, ok := byCategory[extension.Category]; ok {
+			return nil, fmt.Errorf("extension '%s' is registered more than once", extension.Category)
+		}
+		byCategory[extension.Category] = extension
+	}
+	for _, extension := range extensions {
+		for _, dependency := range extension.DependsOn {
+			if _, ok := byCategory[dependency]; !ok && !resources.IsBuiltinCategory(dependency) {
+				return nil, fmt.Errorf("extension '%s' depends on unknown extension '%s'", extension.Category, dependency)
+			}
+		}
+	}
+
+	sorted := make([]Extension, 0, len(extensions))
+	done := map[resources.ResourceCategory]bool{}
+	// extensions on the current dependency path, to detect cycles
+	visiting := map[resources.ResourceCategory]bool{}
+	var visit func(extension Extension, path []string) error
+	visit = func(extension Extension, path []string) error {
+		if done[extension.Category] {
+			return nil
+		}
+		path = append(path, string(extension.Category))
+		if visiting[extension.Category] {
+			return fmt.Errorf("extensions depend on each other: %s", strings.Join(path, " -> "))
+		}
+		visiting[extension.Category] = true
+		for _, dependency := range extension.DependsOn {
+			if dependencyExtension, ok := byCategory[dependency]; ok {
+				if err := visit(dependencyExtension, path); err != nil {
+					return err
+				}
+			}
+		}
+		visiting[extension.Category] = false
+		done[extension.Category] = true
+		sorted = append(sorted, extension)
+		return nil
+	}
+	for _, extension := range extensions {
+		if err := visit(extension, nil); err != nil {
+			return nil, err
+		}
+	}
+	return sorted, nil
+}
+
+// renderExtensions appends the resources of the extensions to the already rendered ones
+func renderExtensions(ctx context.Context, values resources.DeploymentValues, extensions []Extension, rendered []resources.NamedResource) ([]resources.NamedResource, error) {
+	for _, extension := range extensions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		created, err := extension.Create(values, values.Extensions[string(extension.Category)], resources.BuildOutputs(rendered))
+		if err != nil {
+			return nil, fmt.Errorf("extension '%s': %v", extension.Category, err)
+		}
+		for _, r := range created {
+			// the category decides where the resource ends up in the outputs
+			r.Category = extension.Category
+			rendered = append(rendered, r)
+		}
+	}
+	return rendered, nil
+}
+
+// unknownExtensions returns the keys of `extensions` without a registered extension
+func unknownExtensions(values resources.DeploymentValues, extensions []Extension) []string {
+	unknown := []string{}
+	for _, key := range slices.Sorted(maps.Keys(values.Extensions)) {
+		if !slices.ContainsFunc(extensions, func(e Extension) bool { return string(e.Category) == key }) {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
@@ -0,0 +1,118 @@
+package flight
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestExtensions(t *testing.T) {
+	values := []byte(dedent.Dedent(`
+		namespace: foo
+		service: foo
+		component: bar
+		environment: test
+
+		image:
+		  repository: foo
+		  tag: "1.0.0"
+		ports:
+		  - port: 8080
+		globalLabels:
+		  team: payments
+
+		extensions:
+		  backstage:
+		    owner: team-payments
+	`))
+
+	backstage := Extension{
+		Category: "backstage",
+		Create: func(values resources.DeploymentValues, config map[string]interface{}, outputs resources.Outputs) ([]resources.NamedResource, error) {
+			object := unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"owner": config["owner"]}}}
+			object.SetAPIVersion("backstage.io/v1alpha1")
+			object.SetKind("Component")
+			object.SetName(outputs.Workload.Name)
+			object.SetNamespace(values.Metadata.Namespace)
+			return []resources.NamedResource{{Key: "component", Object: object}}, nil
+		},
+	}
+	quota := Extension{
+		Category:  "quota",
+		DependsOn: []resources.ResourceCategory{"backstage"},
+		Create: func(values resources.DeploymentValues, config map[string]interface{}, outputs resources.Outputs) ([]resources.NamedResource, error) {
+			object := unstructured.Unstructured{Object: map[string]interface{}{}}
+			object.SetAPIVersion("example.com/v1")
+			object.SetKind("Quota")
+			object.SetName(outputs.Custom["backstage"]["component"].Name + "-quota")
+			object.SetNamespace(values.Metadata.Namespace)
+			return []resources.NamedResource{{Key: "main", Object: object}}, nil
+		},
+	}
+
+	t.Run("renders the extensions in the order of their dependencies", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{Extensions: []Extension{quota, backstage}})
+		require.NoError(t, err)
+		assert.Empty(t, result.Warnings)
+
+		component := result.Objects[len(result.Objects)-2]
+		assert.Equal(t, "Component", component.GetKind())
+		owner, _, _ := unstructured.NestedString(component.Object, "spec", "owner")
+		assert.Equal(t, "team-payments", owner)
+		// global metadata applies to the extensions' resources too
+		assert.Equal(t, "payments", component.GetLabels()["team"])
+
+		assert.Equal(t, map[string]map[string]resources.Ref{
+			"backstage": {"component": {Name: "foo--bar--test", Namespace: "foo", Kind: "Component"}},
+			"quota":     {"main": {Name: "foo--bar--test-quota", Namespace: "foo", Kind: "Quota"}},
+		}, result.Outputs.Custom)
+	})
+
+	t.Run("warns about config without an extension", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"no extension for 'extensions.backstage', it's ignored"}, result.Warnings)
+		assert.Empty(t, result.Outputs.Custom)
+
+		_, err = Render(context.Background(), values, Options{Strict: true})
+		assert.ErrorContains(t, err, "no extension for 'extensions.backstage'")
+	})
+
+	t.Run("fails on invalid registrations", func(t *testing.T) {
+		cases := map[string]struct {
+			Extensions []Extension
+			Error      string
+		}{
+			"built-in category": {
+				Extensions: []Extension{{Category: resources.CategoryConfigMaps, Create: backstage.Create}},
+				Error:      "category is used by the chart's own resources",
+			},
+			"duplicate": {
+				Extensions: []Extension{backstage, backstage},
+				Error:      "extension 'backstage' is registered more than once",
+			},
+			"unknown dependency": {
+				Extensions: []Extension{quota},
+				Error:      "extension 'quota' depends on unknown extension 'backstage'",
+			},
+			"cycle": {
+				Extensions: []Extension{
+					quota,
+					{Category: "backstage", DependsOn: []resources.ResourceCategory{"quota"}, Create: backstage.Create},
+				},
+				Error: "extensions depend on each other: quota -> backstage -> quota",
+			},
+		}
+		for name, tc := range cases {
+			t.Run(name, func(t *testing.T) {
+				_, err := Render(context.Background(), values, Options{Extensions: tc.Extensions})
+				assert.ErrorContains(t, err, tc.Error)
+			})
+		}
+	})
+}
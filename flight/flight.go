@@ -0,0 +1,252 @@
+// Package flight renders the values into Kubernetes objects - it's the whole Flight, usable as a library, e.g. to render
+// the values in tests or in other Flights
+package flight
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+// Creator decides whether to create the resources, and creates them - the resources carry their category, so they end
+// up in the outputs like the chart's own
+type Creator func(resources.DeploymentValues) (bool, resources.ResourceCreator)
+
+// Encoding of `Result.Encoded`
+type Encoding string
+
+const (
+	// JSON array of the objects, the format yoke expects from a Flight
+	EncodingJSON Encoding = "json"
+	// `---`-separated YAML documents, sorted by kind and name - for kubectl, kubeconform and the like
+	EncodingYAML Encoding = "yaml"
+)
+
+type Options struct {
+	// release yoke renders the values for, the namespace is the default one of the values
+	Release schema.Release
+	// creators rendered after the chart's own ones, their resources can't use the chart's or the extensions' categories
+	Creators []Creator
+	// extensions rendered after the creators, in the order of their dependencies
+	Extensions []Extension
+	// fail on unknown fields in the values instead of warning about them
+	Strict bool
+	// encode the objects into `Result.Encoded`, nothing is encoded when empty
+	Encoding Encoding
+	// annotate the objects with the values paths they were rendered from, and collect `Result.Overrides`
+	Explain bool
+}
+
+type Result struct {
+	Objects  []unstructured.Unstructured
+	Outputs  resources.Outputs
+	Warnings []string
+	Encoded  []byte
+	// fields set by the overrides (`podSpec`, `containerSpec`, `jobSpec`...), only with `Options.Explain`
+	Overrides []resources.Override
+}
+
+// DefaultCreators returns the chart's creators, in the order the objects are rendered
+func DefaultCreators() []Creator {
+	return []Creator{
+		resources.CreateMainWorkload,
+		resources.CreateService,
+		resources.CreateIngress,
+		resources.CreateHttpRoutes,
+		resources.CreateNetworkPolicies,
+		resources.CreateCiliumNetworkPolicies,
+		resources.CreateServiceAccount,
+		resources.CreatePVCs,
+		resources.CreatePreDeploymentJob,
+		resources.CreateCronjobs,
+		resources.CreateExternalSecrets,
+		resources.CreatePushSecrets,
+		resources.CreateSealedSecrets,
+		resources.CreateHPA,
+		resources.CreatePDB,
+		resources.CreateDB,
+		resources.CreateRBAC,
+		resources.CreateConfigMaps,
+		resources.CreateSecrets,
+		resources.CreateDashboards,
+		resources.CreatePrometheusMonitors,
+		resources.CreatePrometheusRules,
+		resources.CreateIstio,
+	}
+}
+
+// Render parses and validates the values, and renders them into the objects. The values can be plain (or SOPS-encrypted)
+// YAML, or the custom resource of the Airway, in which case the custom resource with its status is rendered too
+func Render(ctx context.Context, values []byte, opts Options) (Result, error) {
+	extensions, err := sortExtensions(opts.Extensions)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while registering extensions: %v", err)
+	}
+
+	// run by the ATC, the input is the custom resource
+	source, customResource, err := unwrapCustomResource(values)
+	if err != nil {
+		return Result{}, err
+	}
+	release := opts.Release
+	if customResource != nil && release.Namespace == "" {
+		release.Namespace = customResource.Metadata.Namespace
+	}
+
+	inputValues, warnings, err := parseFromSource(bytes.NewReader(source), release, opts.Strict)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while parsing values: %v", err)
+	}
+
+	deploymentValues, err := resources.PrepareDeploymentValues(inputValues)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while preparing the deployment values: %v", err)
+	}
+
+	if opts.Explain {
+		deploymentValues.Explanation = &resources.Explanation{}
+	}
+
+	if err := resources.ValidateReferences(deploymentValues); err != nil {
+		return Result{}, fmt.Errorf("error while validating references: %v", err)
+	}
+
+	for _, key := range unknownExtensions(deploymentValues, extensions) {
+		if opts.Strict {
+			return Result{}, fmt.Errorf("error while parsing values: no extension for 'extensions.%s'", key)
+		}
+		warnings = append(warnings, fmt.Sprintf("no extension for 'extensions.%s', it's ignored", key))
+	}
+
+	namedResources, err := collectResources(ctx, deploymentValues, DefaultCreators()...)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while rendering resources: %v", err)
+	}
+
+	created, err := collectResources(ctx, deploymentValues, opts.Creators...)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while rendering resources: %v", err)
+	}
+	if err := validateCreatedCategories(created, extensions); err != nil {
+		return Result{}, fmt.Errorf("error while rendering resources: %v", err)
+	}
+	namedResources = append(namedResources, created...)
+
+	namedResources, err = renderExtensions(ctx, deploymentValues, extensions, namedResources)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while rendering extensions: %v", err)
+	}
+
+	if err := resources.ApplyGlobalMetadata(deploymentValues, namedResources); err != nil {
+		return Result{}, fmt.Errorf("error while applying global metadata: %v", err)
+	}
+
+	if err := resources.ValidateNames(namedResources); err != nil {
+		return Result{}, fmt.Errorf("error while validating resource names: %v", err)
+	}
+
+	outputs := resources.BuildOutputs(namedResources)
+
+	extraManifests, err := resources.RenderExtraManifests(deploymentValues, outputs)
+	if err != nil {
+		return Result{}, fmt.Errorf("error while rendering extra manifests: %v", err)
+	}
+
+	if opts.Explain {
+		resources.AnnotateValuesPaths(inputValues, deploymentValues, namedResources, extraManifests)
+	}
+
+	objects := make([]unstructured.Unstructured, 0, len(namedResources)+len(extraManifests))
+	for _, nr := range namedResources {
+		objects = append(objects, nr.Object)
+	}
+	objects = append(objects, extraManifests...)
+	if customResource != nil {
+		status, err := customResourceStatus(*customResource, outputs)
+		if err != nil {
+			return Result{}, fmt.Errorf("error while building the custom resource status: %v", err)
+		}
+		objects = append(objects, status)
+	}
+
+	result := Result{Objects: objects, Outputs: outputs, Warnings: warnings}
+	if deploymentValues.Explanation != nil {
+		result.Overrides = deploymentValues.Explanation.Overrides
+	}
+	if opts.Encoding != "" {
+		result.Encoded, err = Encode(objects, opts.Encoding)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// Encode encodes the objects the same way `Render` does
+func Encode(objects []unstructured.Unstructured, encoding Encoding) ([]byte, error) {
+	switch encoding {
+	case EncodingJSON:
+		jsons := []json.RawMessage{}
+		encoder := k8sjson.NewSerializerWithOptions(
+			k8sjson.DefaultMetaFactory, nil, nil, k8sjson.SerializerOptions{Yaml: false, Strict: true},
+		)
+		for _, r := range objects {
+			bytes, err := runtime.Encode(encoder, &r)
+			if err != nil {
+				return nil, fmt.Errorf("error while serializing resources: %v", err)
+			}
+			jsons = append(jsons, bytes)
+		}
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(jsons); err != nil {
+			return nil, fmt.Errorf("error while encoding resources: %v", err)
+		}
+		return buf.Bytes(), nil
+	case EncodingYAML:
+		return encodeYAMLDocuments(objects)
+	default:
+		return nil, fmt.Errorf("unknown encoding '%s'", encoding)
+	}
+}
+
+func collectResources(ctx context.Context, values resources.DeploymentValues, creators ...Creator) ([]resources.NamedResource, error) {
+	all := []resources.NamedResource{}
+	for _, shouldCreateResource := range creators {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if ok, create := shouldCreateResource(values); ok {
+			if newResources, err := create(values); err != nil {
+				return nil, err
+			} else {
+				all = append(all, newResources...)
+			}
+		}
+
+	}
+	return all, nil
+}
+
+// validateCreatedCategories checks that the resources of `Options.Creators` don't overwrite the outputs of the chart's
+// own resources or of the extensions
+func validateCreatedCategories(created []resources.NamedResource, extensions []Extension) error {
+	for _, r := range created {
+		switch {
+		case r.Category == "":
+			return fmt.Errorf("%s '%s': category is required", r.Object.GetKind(), r.Object.GetName())
+		case resources.IsBuiltinCategory(r.Category):
+			return fmt.Errorf("%s '%s': category '%s' is used by the chart's own resources", r.Object.GetKind(), r.Object.GetName(), r.Category)
+		case slices.ContainsFunc(extensions, func(e Extension) bool { return e.Category == r.Category }):
+			return fmt.Errorf("%s '%s': category '%s' is used by an extension", r.Object.GetKind(), r.Object.GetName(), r.Category)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package flight
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRender(t *testing.T) {
+	values := []byte(dedent.Dedent(`
+		namespace: foo
+		service: foo
+		component: bar
+		environment: test
+
+		image:
+		  repository: foo
+		  tag: "1.0.0"
+		ports:
+		  - port: 8080
+	`))
+
+	t.Run("renders the objects and outputs", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{Encoding: EncodingJSON})
+		require.NoError(t, err)
+		assert.Empty(t, result.Warnings)
+		require.NotNil(t, result.Outputs.Workload)
+		assert.Equal(t, "foo--bar--test", result.Outputs.Workload.Name)
+
+		var encoded []map[string]interface{}
+		require.NoError(t, json.Unmarshal(result.Encoded, &encoded))
+		require.Len(t, encoded, len(result.Objects))
+		for i, object := range result.Objects {
+			assert.Equal(t, object.GetKind(), encoded[i]["kind"])
+		}
+	})
+
+	t.Run("doesn't encode without encoding", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{})
+		require.NoError(t, err)
+		assert.NotEmpty(t, result.Objects)
+		assert.Nil(t, result.Encoded)
+	})
+
+	widgets := func(category resources.ResourceCategory) Creator {
+		return func(values resources.DeploymentValues) (bool, resources.ResourceCreator) {
+			return true, func(values resources.DeploymentValues) ([]resources.NamedResource, error) {
+				object := unstructured.Unstructured{}
+				object.SetAPIVersion("example.com/v1")
+				object.SetKind("Widget")
+				object.SetName(values.Metadata.Service + "-widget")
+				object.SetNamespace(values.Metadata.Namespace)
+				return []resources.NamedResource{{Category: category, Key: "widget", Object: object}}, nil
+			}
+		}
+	}
+
+	t.Run("renders custom creators after the chart's own", func(t *testing.T) {
+		result, err := Render(context.Background(), values, Options{Creators: []Creator{widgets("widgets")}})
+		require.NoError(t, err)
+		last := result.Objects[len(result.Objects)-1]
+		assert.Equal(t, "Widget", last.GetKind())
+		assert.Equal(t, "foo-widget", result.Outputs.Custom["widgets"]["widget"].Name)
+	})
+
+	t.Run("fails on custom creators using taken categories", func(t *testing.T) {
+		_, err := Render(context.Background(), values, Options{Creators: []Creator{widgets(resources.CategoryConfigMaps)}})
+		assert.ErrorContains(t, err, "Widget 'foo-widget': category 'ConfigMaps' is used by the chart's own resources")
+
+		extension := Extension{
+			Category: "widgets",
+			Create: func(resources.DeploymentValues, map[string]interface{}, resources.Outputs) ([]resources.NamedResource, error) {
+				return nil, nil
+			},
+		}
+		_, err = Render(context.Background(), values, Options{Creators: []Creator{widgets("widgets")}, Extensions: []Extension{extension}})
+		assert.ErrorContains(t, err, "Widget 'foo-widget': category 'widgets' is used by an extension")
+	})
+
+	t.Run("warns about unknown fields", func(t *testing.T) {
+		withTypo := append([]byte("replicaz: 3\n"), values...)
+		result, err := Render(context.Background(), withTypo, Options{})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"unknown field 'replicaz'"}, result.Warnings)
+
+		_, err = Render(context.Background(), withTypo, Options{Strict: true})
+		assert.ErrorContains(t, err, "unknown field 'replicaz'")
+	})
+
+	t.Run("stops when the context is done", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := Render(ctx, values, Options{})
+		assert.ErrorContains(t, err, context.Canceled.Error())
+	})
+}
@@ -0,0 +1,135 @@
+package flight
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yaml "github.com/goccy/go-yaml"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
+)
+
+// kinds in the order they can be applied in - namespaced config before the workloads using it, same as Helm does
+var kindPriority = []string{
+	"Namespace",
+	"NetworkPolicy",
+	"ResourceQuota",
+	"LimitRange",
+	"PodDisruptionBudget",
+	"ServiceAccount",
+	"Secret",
+	"ConfigMap",
+	"StorageClass",
+	"PersistentVolume",
+	"PersistentVolumeClaim",
+	"CustomResourceDefinition",
+	"ClusterRole",
+	"ClusterRoleBinding",
+	"Role",
+	"RoleBinding",
+	"Service",
+	"DaemonSet",
+	"Pod",
+	"ReplicaSet",
+	"Deployment",
+	"HorizontalPodAutoscaler",
+	"StatefulSet",
+	"Job",
+	"CronJob",
+	"IngressClass",
+	"Ingress",
+	"APIService",
+}
+
+// SortObjects returns the objects sorted by their kind's priority, other kinds come last in alphabetical order, then by
+// name and namespace
+func SortObjects(objects []unstructured.Unstructured) []unstructured.Unstructured {
+	priority := func(kind string) int {
+		if i := slices.Index(kindPriority, kind); i != -1 {
+			return i
+		}
+		return len(kindPriority)
+	}
+	sorted := slices.Clone(objects)
+	slices.SortStableFunc(sorted, func(a, b unstructured.Unstructured) int {
+		if p := priority(a.GetKind()) - priority(b.GetKind()); p != 0 {
+			return p
+		}
+		if c := strings.Compare(a.GetKind(), b.GetKind()); c != 0 {
+			return c
+		}
+		if c := strings.Compare(a.GetName(), b.GetName()); c != 0 {
+			return c
+		}
+		return strings.Compare(a.GetNamespace(), b.GetNamespace())
+	})
+	return sorted
+}
+
+func encodeYAMLDocuments(objects []unstructured.Unstructured) ([]byte, error) {
+	var buf bytes.Buffer
+	for _, object := range SortObjects(objects) {
+		document, err := encodeYAML(object)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(document)
+	}
+	return buf.Bytes(), nil
+}
+
+// encodeYAML converts the object's JSON, which has the fields sorted, to YAML keeping the order - the Kubernetes YAML
+// serializer sorts keys such as `k30`/`k6a` in the order it gets them, so the output would change between runs
+func encodeYAML(object unstructured.Unstructured) ([]byte, error) {
+	encoder := k8sjson.NewSerializerWithOptions(
+		k8sjson.DefaultMetaFactory, nil, nil, k8sjson.SerializerOptions{Yaml: false, Strict: true},
+	)
+	encoded, err := runtime.Encode(encoder, &object)
+	if err != nil {
+		return nil, fmt.Errorf("error while serializing %s '%s': %v", object.GetKind(), object.GetName(), err)
+	}
+	document, err := yaml.JSONToYAML(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("error while serializing %s '%s': %v", object.GetKind(), object.GetName(), err)
+	}
+	return document, nil
+}
+
+// WriteDir writes every object into its own YAML file under `dir`, in the same layout as `yoke takeoff -out`:
+// `{namespace}/{group}/{version}/{kind}/{name}.yaml`, `_` being the namespace of cluster-scoped objects and `core` the
+// group of the core API. Files of objects which are no longer rendered aren't removed
+func WriteDir(dir string, objects []unstructured.Unstructured) error {
+	for _, object := range objects {
+		document, err := encodeYAML(object)
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, objectPath(object))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return fmt.Errorf("error while creating directory for %s '%s': %v", object.GetKind(), object.GetName(), err)
+		}
+		if err := os.WriteFile(path, document, 0o644); err != nil {
+			return fmt.Errorf("error while writing %s '%s': %v", object.GetKind(), object.GetName(), err)
+		}
+	}
+	return nil
+}
+
+func objectPath(object unstructured.Unstructured) string {
+	namespace := object.GetNamespace()
+	if namespace == "" {
+		namespace = "_"
+	}
+	gvk := object.GroupVersionKind()
+	group := gvk.Group
+	if group == "" {
+		group = "core"
+	}
+	return filepath.Join(namespace, group, gvk.Version, strings.ToLower(gvk.Kind), object.GetName()+".yaml")
+}
@@ -0,0 +1,71 @@
+package flight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestOutput(t *testing.T) {
+	object := func(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+		u := unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetNamespace(namespace)
+		u.SetName(name)
+		return u
+	}
+	objects := []unstructured.Unstructured{
+		object("apps/v1", "Deployment", "ns", "app"),
+		object("example.com/v1", "Widget", "ns", "b"),
+		object("v1", "Service", "ns", "b"),
+		object("v1", "Service", "ns", "a"),
+		object("example.com/v1", "Gadget", "ns", "z"),
+		object("rbac.authorization.k8s.io/v1", "ClusterRole", "", "role"),
+		object("v1", "ConfigMap", "ns", "config"),
+	}
+
+	t.Run("sorts by kind priority, then by name", func(t *testing.T) {
+		names := []string{}
+		for _, o := range SortObjects(objects) {
+			names = append(names, o.GetKind()+"/"+o.GetName())
+		}
+		assert.Equal(t, []string{
+			"ConfigMap/config",
+			"ClusterRole/role",
+			"Service/a",
+			"Service/b",
+			"Deployment/app",
+			"Gadget/z",
+			"Widget/b",
+		}, names)
+		// the input isn't modified
+		assert.Equal(t, "Deployment", objects[0].GetKind())
+	})
+
+	t.Run("encodes YAML documents", func(t *testing.T) {
+		encoded, err := Encode(objects[2:4], EncodingYAML)
+		require.NoError(t, err)
+		documents := strings.Split(string(encoded), "---\n")
+		require.Len(t, documents, 3)
+		assert.Empty(t, documents[0])
+		assert.Contains(t, documents[1], "name: a\n")
+		assert.Contains(t, documents[2], "name: b\n")
+	})
+
+	t.Run("writes a file per object", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, WriteDir(dir, objects))
+
+		deployment, err := os.ReadFile(filepath.Join(dir, "ns", "apps", "v1", "deployment", "app.yaml"))
+		require.NoError(t, err)
+		assert.Contains(t, string(deployment), "kind: Deployment\n")
+		assert.FileExists(t, filepath.Join(dir, "ns", "core", "v1", "service", "a.yaml"))
+		assert.FileExists(t, filepath.Join(dir, "_", "rbac.authorization.k8s.io", "v1", "clusterrole", "role.yaml"))
+	})
+}
@@ -0,0 +1,68 @@
+package flight
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/go-playground/validator/v10"
+	yaml "github.com/goccy/go-yaml"
+)
+
+// ReleaseFromEnv returns the release yoke renders the Flight for - yoke passes it to the Flight as env variables
+func ReleaseFromEnv() schema.Release {
+	return schema.Release{
+		Name:      os.Getenv("YOKE_RELEASE"),
+		Namespace: os.Getenv("YOKE_NAMESPACE"),
+	}
+}
+
+// parseFromSource parses and validates the values. Unknown fields are returned as warnings, or fail the parsing when
+// `strict`
+func parseFromSource(r io.Reader, release schema.Release, strict bool) (schema.InputValues, []string, error) {
+	var values schema.InputValues
+	bytes, err := io.ReadAll(r)
+	if err != nil {
+		return schema.InputValues{}, nil, fmt.Errorf("stdin read error: %v", err)
+	}
+	decrypted, isEncrypted, err := decryptSops(bytes)
+	if err != nil {
+		return schema.InputValues{}, nil, fmt.Errorf("sops decryption error: %v", err)
+	}
+	if isEncrypted {
+		// the error must not include the source, that would print the decrypted values
+		if err := yaml.Unmarshal(decrypted, &values); err != nil {
+			return schema.InputValues{}, nil, fmt.Errorf("unmarshalling error: %v", yaml.FormatError(err, false, false))
+		}
+		bytes = decrypted
+	} else if err := yaml.Unmarshal(bytes, &values); err != nil {
+		return schema.InputValues{}, nil, fmt.Errorf("unmarshalling error: %v", err)
+	}
+
+	warnings := []string{}
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(bytes, &raw); err == nil {
+		for _, field := range schema.UnknownFields(raw) {
+			if strict {
+				return schema.InputValues{}, nil, fmt.Errorf("unmarshalling error: unknown field '%s'", field)
+			}
+			warnings = append(warnings, fmt.Sprintf("unknown field '%s'", field))
+		}
+	}
+
+	values.Release = release
+	if values.Namespace == "" {
+		values.Namespace = release.Namespace
+	}
+	// unmarshal doesn't validate fields being required (`string` vs `*string`), just parses the YAML into struct
+	// to validate required fields or others, need the validator package too
+	validate := validator.New(validator.WithRequiredStructEnabled())
+	if err := validate.Struct(values); err != nil {
+		return schema.InputValues{}, nil, fmt.Errorf("validation error: %v", err)
+	}
+	if err := schema.CustomValidations(values); err != nil {
+		return schema.InputValues{}, nil, fmt.Errorf("custom validation error: %v", err)
+	}
+	return values, warnings, nil
+}
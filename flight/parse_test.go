@@ -0,0 +1,1456 @@
+package flight
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/lithammer/dedent"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// meant for testing the parsing mechanism and custom validation logic etc.
+func TestParse(t *testing.T) {
+	type CaseConfig struct {
+		// can contain arbitrary whitespace around it to make it pretty in code
+		// but watch tabs/spaces => YAML can't handle tabs that are default indent in Go
+		Input string
+		// the release yoke would pass to the Flight
+		Release schema.Release
+		Asserts func(*testing.T, schema.InputValues, error)
+	}
+
+	cases := map[string]CaseConfig{
+		"passes empty Kind": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes Kind = Deployment": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        kind: Deployment
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes Kind = StatefulSet": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        kind: StatefulSet
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"fails a non-enum Kind value": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        kind: foo
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"fails a node port out of its allowed range - lower bound": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        ports:
+          - port: 80
+            nodePort: 2500
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"fails a node port out of its allowed range - upper bound": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        ports:
+          - port: 80
+            nodePort: 34000
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"passes httpRoute (singular) alone": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        httpRoute:
+          parentRefs:
+            - name: prod-gateway
+          hostnames:
+            - myapp.example.com
+          rules:
+            - backendRefs:
+                - name: myapp
+                  port: 8080
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				assert.NotNil(t, iv.HTTPRoute)
+				assert.Nil(t, iv.HTTPRoutes)
+			},
+		},
+		"passes httpRoutes (plural) alone": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        httpRoutes:
+          public:
+            parentRefs:
+              - name: prod-gateway
+            hostnames:
+              - api.example.com
+            rules:
+              - backendRefs:
+                  - name: myapp
+                    port: 8080
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				assert.Nil(t, iv.HTTPRoute)
+				assert.Contains(t, iv.HTTPRoutes, "public")
+			},
+		},
+		"accepts a single volume mount object (backwards compatible shape)": {
+			Input: `
+        namespace: foo
+        service: payments-api
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          app-config:
+            type: configMap
+            configMapName: payments-api-config
+            mounts:
+              main:
+                containerPath: /etc/payments-api
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				require.Len(t, iv.Volumes["app-config"].Mounts["main"], 1)
+				assert.Equal(t, "/etc/payments-api", iv.Volumes["app-config"].Mounts["main"][0].ContainerPath)
+			},
+		},
+		"accepts multiple volume mounts as a list for the same container": {
+			Input: `
+        namespace: foo
+        service: payments-api
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          app-config:
+            type: configMap
+            configMapName: payments-api-config
+            mounts:
+              main:
+                - containerPath: /etc/payments-api/app.yaml
+                  volumePath: app.yaml
+                - containerPath: /etc/payments-api/logging.yaml
+                  volumePath: logging.yaml
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				mounts := iv.Volumes["app-config"].Mounts["main"]
+				require.Len(t, mounts, 2)
+				assert.Equal(t, "/etc/payments-api/app.yaml", mounts[0].ContainerPath)
+				assert.Equal(t, "/etc/payments-api/logging.yaml", mounts[1].ContainerPath)
+			},
+		},
+		"fails when a volume mount inside a list is missing its containerPath": {
+			Input: `
+        namespace: foo
+        service: payments-api
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          app-config:
+            type: configMap
+            configMapName: payments-api-config
+            mounts:
+              main:
+                - containerPath: /etc/payments-api/app.yaml
+                - volumePath: logging.yaml
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"fails on an invalid mountPropagation value": {
+			Input: `
+        namespace: foo
+        service: payments-api
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          scratch:
+            type: local
+            mounts:
+              main:
+                containerPath: /var/scratch
+                mountPropagation: Sideways
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses ciliumNetworkPolicies with FQDN and L7 HTTP rules": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        ciliumNetworkPolicies:
+          stripe:
+            egress:
+              - toFQDNs:
+                  - matchName: api.stripe.com
+                toPorts:
+                  - ports:
+                      - port: 443
+                        protocol: TCP
+                    rules:
+                      http:
+                        - method: POST
+                          path: /v1/charges
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				egress := iv.CiliumNetworkPolicies["stripe"].Egress[0]
+				assert.Equal(t, "api.stripe.com", egress.ToFQDNs[0].MatchName)
+				assert.Equal(t, "443", egress.ToPorts[0].Ports[0].Port)
+				assert.Equal(t, "POST", egress.ToPorts[0].Rules.HTTP[0].Method)
+			},
+		},
+		"fails a ciliumNetworkPolicies FQDN selector with both matchName and matchPattern": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        ciliumNetworkPolicies:
+          stripe:
+            egress:
+              - toFQDNs:
+                  - matchName: api.stripe.com
+                    matchPattern: "*.stripe.com"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses istio": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        istio:
+          injection:
+            enabled: true
+          virtualService:
+            http:
+              - timeout: 5s
+                retries:
+                  attempts: 3
+          peerAuthentication:
+            mtls:
+              mode: STRICT
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.True(t, *iv.Istio.Injection.Enabled)
+				assert.Equal(t, "5s", iv.Istio.VirtualService.HTTP[0].Timeout)
+				assert.Equal(t, "STRICT", iv.Istio.PeerAuthentication.Mtls.Mode)
+			},
+		},
+		"fails istio with an unknown mTLS mode": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        istio:
+          peerAuthentication:
+            mtls:
+              mode: STRICTER
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses additional services": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        services:
+          metrics:
+            ports: [main-port]
+            type: LoadBalancer
+            spec:
+              externalTrafficPolicy: Local
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"main-port"}, iv.Services["metrics"].Ports)
+				assert.Equal(t, corev1.ServiceTypeLoadBalancer, iv.Services["metrics"].Type)
+				assert.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, iv.Services["metrics"].Spec.ExternalTrafficPolicy)
+			},
+		},
+		"fails additional services without ports or containers": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        services:
+          metrics:
+            type: LoadBalancer
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses serviceMonitor and podMonitor ports shorthand": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        serviceMonitor:
+          enabled: true
+          ports: [main-port]
+        podMonitor:
+          enabled: true
+          ports: [metrics]
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"main-port"}, iv.ServiceMonitor.Ports)
+				assert.Equal(t, []string{"metrics"}, iv.PodMonitor.Ports)
+			},
+		},
+		"fails serviceMonitor without ports or endpoints": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        serviceMonitor:
+          enabled: true
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses prometheusRules with built-in alerts": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        cronjobs:
+          - name: cleanup
+            schedule: "0 3 * * *"
+            image:
+              repository: foo
+              tag: bleh
+
+        volumes:
+          data:
+            type: persistent
+            existing: false
+            size: 1Gi
+            storageClassName: standard
+            accessModes: [ReadWriteOnce]
+            mounts:
+              main:
+                - containerPath: /data
+
+        prometheusRules:
+          groups:
+            - name: custom
+              rules:
+                - alert: Custom
+                  expr: up == 0
+          alerts:
+            podCrashLooping:
+              severity: critical
+            cronjobNotScheduled:
+              within: 25h
+            pvcUsage:
+              threshold: 90
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "up == 0", iv.PrometheusRules.Groups[0].Rules[0].Expr.StrVal)
+				assert.Equal(t, "critical", iv.PrometheusRules.Alerts.PodCrashLooping.Severity)
+				assert.Equal(t, "25h", string(iv.PrometheusRules.Alerts.CronjobNotScheduled.Within))
+				assert.Equal(t, 90, *iv.PrometheusRules.Alerts.PVCUsage.Threshold)
+			},
+		},
+		"fails hpaMaxedOut alert without autoscaling": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        prometheusRules:
+          alerts:
+            hpaMaxedOut: {}
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "hpaMaxedOut requires `autoscaling`")
+			},
+		},
+		"fails pvcUsage alert without PVCs": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        prometheusRules:
+          alerts:
+            pvcUsage: {}
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "pvcUsage requires a new `persistent` volume")
+			},
+		},
+		"fails cronjobNotScheduled alert with invalid duration": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        cronjobs:
+          - name: cleanup
+            schedule: "0 3 * * *"
+            image:
+              repository: foo
+              tag: bleh
+
+        prometheusRules:
+          alerts:
+            cronjobNotScheduled:
+              within: a day
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses slos": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        slos:
+          - name: availability
+            objective: 99.9
+            window: 28d
+            sli:
+              events:
+                goodQuery: sum(rate(http_requests_total{ {{.selector}}, code!~"5.."}[{{.window}}]))
+                totalQuery: sum(rate(http_requests_total{ {{.selector}} }[{{.window}}]))
+          - name: latency
+            objective: 95
+            sli:
+              latency:
+                metric: http_request_duration_seconds
+                threshold: "0.5"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				require.Len(t, iv.SLOs, 2)
+				assert.Equal(t, 99.9, iv.SLOs[0].Objective)
+				assert.Equal(t, "0.5", iv.SLOs[1].SLI.Latency.Threshold)
+			},
+		},
+		"fails slos with both events and latency SLI": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        slos:
+          - name: availability
+            objective: 99.9
+            sli:
+              events:
+                goodQuery: good
+                totalQuery: total
+              latency:
+                metric: http_request_duration_seconds
+                threshold: "0.5"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"fails slos with duplicate names": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        slos:
+          - name: availability
+            objective: 99.9
+            sli:
+              events:
+                goodQuery: good
+                totalQuery: total
+          - name: availability
+            objective: 99
+            sli:
+              events:
+                goodQuery: good
+                totalQuery: total
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "duplicate SLO name")
+			},
+		},
+		"parses dashboards": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        dashboards:
+          overview:
+            builtin: {}
+          custom:
+            folder: Team A
+            json: |
+              {"uid": "custom", "title": "Custom"}
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.NotNil(t, iv.Dashboards["overview"].Builtin)
+				assert.Equal(t, "Team A", iv.Dashboards["custom"].Folder)
+			},
+		},
+		"fails dashboards with invalid JSON": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        dashboards:
+          custom:
+            json: "{not json"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"fails dashboards with both builtin and JSON": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        dashboards:
+          custom:
+            builtin: {}
+            json: "{}"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+		"parses otel": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        otel:
+          endpoint: http://collector.monitoring:4317
+          protocol: grpc
+          resourceAttributes:
+            team: payments
+          instrumentation:
+            main: java
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "http://collector.monitoring:4317", iv.Otel.Endpoint)
+				assert.Equal(t, "payments", iv.Otel.ResourceAttributes["team"])
+				assert.Equal(t, "java", iv.Otel.Instrumentation["main"])
+			},
+		},
+		"fails otel instrumentation of an unknown container": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        otel:
+          instrumentation:
+            worker: java
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.ErrorContains(t, err, `container "worker" not found`)
+			},
+		},
+		"fails otel instrumentation with an unknown language": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        otel:
+          instrumentation:
+            main: cobol
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"parses external secrets mounted as files": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            as: volume
+            mapping:
+              certs/tls: null
+            volume:
+              mountPath: /etc/tls
+              files:
+                tls.key:
+                  path: key.pem
+                  mode: 0400
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "/etc/tls", iv.ExternalSecrets[0].Volume.MountPath)
+				assert.Equal(t, "key.pem", iv.ExternalSecrets[0].Volume.Files["tls.key"].Path)
+				assert.Equal(t, int32(0400), *iv.ExternalSecrets[0].Volume.Files["tls.key"].Mode)
+			},
+		},
+		"fails external secret as volume without volume config": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            as: volume
+            mapping:
+              certs/tls: null
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"parses external secret templates and strategies": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            decodingStrategy: Auto
+            keys:
+              CERT:
+                decodingStrategy: Base64
+            mapping:
+              certs:
+                CERT: null
+            template:
+              type: kubernetes.io/tls
+              data:
+                tls.crt: "{{ .cert }}"
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				definition := iv.ExternalSecrets[0]
+				assert.Equal(t, "Auto", string(*definition.DecodingStrategy))
+				assert.Equal(t, "Base64", string(*definition.Keys["CERT"].DecodingStrategy))
+				assert.Equal(t, "kubernetes.io/tls", string(definition.Template.Type))
+				assert.Equal(t, "{{ .cert }}", definition.Template.Data["tls.crt"])
+			},
+		},
+		"fails external secret with an unknown decoding strategy": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            decodingStrategy: Hex
+            mapping:
+              certs: null
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"parses generated external secrets": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - generate:
+              name: session-key
+              mapping:
+                SESSION_KEY: password
+              password:
+                length: 32
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "session-key", iv.ExternalSecrets[0].Generate.Name)
+				assert.Equal(t, 32, iv.ExternalSecrets[0].Generate.Password.Length)
+			},
+		},
+		"fails external secret with both mapping and generate": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            mapping:
+              path/to/secret: null
+            generate:
+              name: session-key
+              password:
+                length: 32
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"fails external secret with both secretStore and generate": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            generate:
+              name: session-key
+              password:
+                length: 32
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, "'SecretStore' failed on the 'excluded_with' tag")
+			},
+		},
+		"fails external secret without a store": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        externalSecrets:
+          - mapping:
+              path/to/secret: null
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"parses push secrets": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        db:
+          enabled: true
+          clusterName: pg
+          replicas: 1
+          version: 15
+          size: 1Gi
+          storageClass: standard
+          users:
+            app: []
+          databases:
+            app: app
+
+        pushSecrets:
+          db:
+            secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            remotePath: teams/foo/db
+            dbUser: app
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "app", iv.PushSecrets["db"].DBUser)
+				assert.Equal(t, "teams/foo/db", iv.PushSecrets["db"].RemotePath)
+			},
+		},
+		"fails push secret of an unknown DB user": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        db:
+          enabled: true
+          clusterName: pg
+          replicas: 1
+          version: 15
+          size: 1Gi
+          storageClass: standard
+          users:
+            app: []
+          databases:
+            app: app
+
+        pushSecrets:
+          db:
+            secretStore:
+              name: vault
+              kind: ClusterSecretStore
+            remotePath: teams/foo/db
+            dbUser: admin
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.ErrorContains(t, err, `user "admin" not found`)
+			},
+		},
+		"fails push secret without a secret store": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        pushSecrets:
+          tls:
+            remotePath: teams/foo/tls
+            secret: foo-tls
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.Error(t, err)
+			},
+		},
+		"passes sealedSecrets": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        sealedSecrets:
+          - name: db
+            scope: cluster-wide
+            encryptedData:
+              PASSWORD: AgBy3i4OJSWK
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				require.Len(t, iv.SealedSecrets, 1)
+				assert.Equal(t, "cluster-wide", iv.SealedSecrets[0].Scope)
+			},
+		},
+		"fails sealedSecrets with unknown scope": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        sealedSecrets:
+          - name: db
+            scope: global
+            encryptedData:
+              PASSWORD: AgBy3i4OJSWK
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "Scope")
+			},
+		},
+		"fails sealedSecrets without encryptedData": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        sealedSecrets:
+          - name: db
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "EncryptedData")
+			},
+		},
+		"passes configMap volume with the configMap shorthand": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          config:
+            type: configMap
+            configMap: app
+            mounts:
+              main:
+                containerPath: /etc/config
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "app", iv.Volumes["config"].Variant.(schema.ConfigMapVolume).ConfigMap)
+			},
+		},
+		"fails configMap volume with both configMap and configMapName": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          config:
+            type: configMap
+            configMap: app
+            configMapName: other
+            mounts:
+              main:
+                containerPath: /etc/config
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "ConfigMapName")
+			},
+		},
+		"fails configMap volume without configMap or configMapName": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        volumes:
+          config:
+            type: configMap
+            mounts:
+              main:
+                containerPath: /etc/config
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "ConfigMap")
+			},
+		},
+		"fails port names over 15 characters": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        ports:
+          - port: 8080
+            name: http-metrics-admin
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "invalid name 'http-metrics-admin' of port 8080 of the main container")
+			},
+		},
+		"fails invalid sidecar port names": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        sidecars:
+          proxy:
+            image:
+              repository: proxy
+              tag: "1"
+            ports:
+              - port: 9090
+                name: Admin_Port
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "invalid name 'Admin_Port' of port 9090 of sidecar proxy")
+			},
+		},
+		"passes globalLabels and selectorLabels": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        selectorLabels: recommended
+        globalLabels:
+          example.com/team: payments
+        globalAnnotations:
+          example.com/owner: payments@example.com
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, "recommended", *iv.SelectorLabels)
+				assert.Equal(t, map[string]string{"example.com/team": "payments"}, iv.GlobalLabels)
+			},
+		},
+		"fails invalid selectorLabels": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        selectorLabels: kubernetes
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "SelectorLabels")
+			},
+		},
+		"fails invalid globalLabels value": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        globalLabels:
+          team: payments team
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "invalid value of globalLabels key 'team'")
+			},
+		},
+		"fails invalid globalAnnotations key": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        globalAnnotations:
+          owner/of/service: payments
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "invalid globalAnnotations key 'owner/of/service'")
+			},
+		},
+		"defaults namespace to the release namespace": {
+			Input: `
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+      `,
+			Release: schema.Release{Name: "foo-release", Namespace: "release-ns"},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "release-ns", iv.Namespace)
+				assert.Equal(t, "foo-release", iv.Release.Name)
+			},
+		},
+		"prefers explicit namespace over the release namespace": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+      `,
+			Release: schema.Release{Name: "foo-release", Namespace: "release-ns"},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "foo", iv.Namespace)
+			},
+		},
+		"fails without namespace outside of yoke": {
+			Input: `
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "'Namespace' failed on the 'required' tag")
+			},
+		},
+		"fails when both httpRoute and httpRoutes are set": {
+			Input: `
+        namespace: foo
+        service: foo
+        component: bar
+        environment: test
+
+        image:
+          repository: foo
+          tag: bleh
+
+        httpRoute:
+          parentRefs:
+            - name: prod-gateway
+          hostnames:
+            - myapp.example.com
+          rules:
+            - backendRefs:
+                - name: myapp
+                  port: 8080
+        httpRoutes:
+          public:
+            parentRefs:
+              - name: prod-gateway
+            hostnames:
+              - api.example.com
+            rules:
+              - backendRefs:
+                  - name: myapp
+                    port: 8080
+      `,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			input := dedent.Dedent(tc.Input)
+			reader := strings.NewReader(strings.TrimSpace(input))
+
+			values, _, err := parseFromSource(reader, tc.Release, false)
+			tc.Asserts(t, values, err)
+		})
+	}
+}
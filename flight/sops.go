@@ -0,0 +1,263 @@
+package flight
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	yaml "github.com/goccy/go-yaml"
+)
+
+// values can be encrypted with SOPS (https://github.com/getsops/sops) using age recipients, the file is decrypted
+// in place before parsing - same key env vars as the sops CLI, so the CMP sidecar can just mount the key file
+//
+// none of the errors in here may contain the decrypted values (or the key), they end up in ArgoCD UI
+
+const (
+	sopsAgeKeyEnv     = "SOPS_AGE_KEY"
+	sopsAgeKeyFileEnv = "SOPS_AGE_KEY_FILE"
+)
+
+var sopsValueRegex = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.+),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// SOPS initializes the MAC with these bytes when only the encrypted values are part of it
+var sopsMACOnlyEncryptedInitialization = []byte{0x8a, 0x3f, 0xd2, 0xad, 0x54, 0xce, 0x66, 0x52, 0x7b, 0x10, 0x34, 0xf3, 0xd1, 0x47, 0xbe, 0xb, 0xb, 0x97, 0x5b, 0x3b, 0xf4, 0x4f, 0x72, 0xc6, 0xfd, 0xad, 0xec, 0x81, 0x76, 0xf2, 0x7d, 0x69}
+
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `json:"recipient"`
+		Enc       string `json:"enc"`
+	} `json:"age"`
+	LastModified     string `json:"lastmodified"`
+	MAC              string `json:"mac"`
+	MACOnlyEncrypted bool   `json:"mac_only_encrypted"`
+}
+
+// returns the decrypted YAML without the `sops` metadata, `false` if the source isn't SOPS-encrypted at all
+func decryptSops(source []byte) ([]byte, bool, error) {
+	var document yaml.MapSlice
+	// invalid YAML is reported by the regular unmarshalling later
+	if err := yaml.UnmarshalWithOptions(source, &document, yaml.UseOrderedMap()); err != nil {
+		return nil, false, nil
+	}
+	metadataIndex := slices.IndexFunc(document, func(item yaml.MapItem) bool { return item.Key == "sops" })
+	if metadataIndex == -1 {
+		return nil, false, nil
+	}
+
+	rawMetadata, err := yaml.Marshal(document[metadataIndex].Value)
+	if err != nil {
+		return nil, true, fmt.Errorf("error while reading sops metadata: %v", err)
+	}
+	var metadata sopsMetadata
+	if err := yaml.Unmarshal(rawMetadata, &metadata); err != nil {
+		return nil, true, fmt.Errorf("error while reading sops metadata: %v", yaml.FormatError(err, false, false))
+	}
+	key, err := sopsDataKey(metadata)
+	if err != nil {
+		return nil, true, err
+	}
+
+	decryptor := sopsDecryptor{key: key, mac: sha512.New(), macOnlyEncrypted: metadata.MACOnlyEncrypted}
+	if metadata.MACOnlyEncrypted {
+		decryptor.mac.Write(sopsMACOnlyEncryptedInitialization)
+	}
+	document = slices.Delete(document, metadataIndex, metadataIndex+1)
+	if _, err := decryptor.walk(document, []string{}); err != nil {
+		return nil, true, err
+	}
+
+	// the MAC covers the values, without checking it the (unencrypted) rest of the file could be tampered with
+	if metadata.MAC == "" {
+		return nil, true, errors.New("sops metadata has no MAC")
+	}
+	mac, err := decryptor.decrypt(metadata.MAC, metadata.LastModified)
+	if err != nil {
+		return nil, true, fmt.Errorf("could not decrypt the sops MAC: %v", err)
+	}
+	if mac != fmt.Sprintf("%X", decryptor.mac.Sum(nil)) {
+		return nil, true, errors.New("sops MAC mismatch, the file was modified without sops")
+	}
+
+	decrypted, err := yaml.Marshal(document)
+	if err != nil {
+		return nil, true, fmt.Errorf("error while serializing decrypted values: %v", err)
+	}
+	return decrypted, true, nil
+}
+
+// decrypts the data key from any of the age recipients the provided identities can open
+func sopsDataKey(metadata sopsMetadata) ([]byte, error) {
+	if len(metadata.Age) == 0 {
+		return nil, errors.New("no age recipients in sops metadata, only age is supported")
+	}
+	identities, err := loadAgeIdentities()
+	if err != nil {
+		return nil, err
+	}
+	for _, recipient := range metadata.Age {
+		r, err := age.Decrypt(armor.NewReader(strings.NewReader(recipient.Enc)), identities...)
+		if err != nil {
+			continue
+		}
+		key, err := io.ReadAll(r)
+		if err != nil {
+			return nil, fmt.Errorf("error while decrypting the data key for recipient %s: %v", recipient.Recipient, err)
+		}
+		return key, nil
+	}
+	return nil, errors.New("none of the provided age keys can decrypt the sops data key")
+}
+
+func loadAgeIdentities() ([]age.Identity, error) {
+	var (
+		source string
+		r      io.Reader
+	)
+	if key := os.Getenv(sopsAgeKeyEnv); key != "" {
+		source, r = sopsAgeKeyEnv, strings.NewReader(key)
+	} else if path := os.Getenv(sopsAgeKeyFileEnv); path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("error while reading age key file %s: %v", path, err)
+		}
+		defer f.Close()
+		source, r = path, f
+	} else {
+		return nil, fmt.Errorf("values are encrypted with sops, but no age key was provided (set %s or %s)", sopsAgeKeyEnv, sopsAgeKeyFileEnv)
+	}
+	identities, err := age.ParseIdentities(r)
+	if err != nil {
+		// the parse error can quote the key itself
+		return nil, fmt.Errorf("could not parse age key from %s", source)
+	}
+	return identities, nil
+}
+
+type sopsDecryptor struct {
+	key              []byte
+	mac              hash.Hash
+	macOnlyEncrypted bool
+}
+
+// decrypts all values in place, in document order (the MAC depends on it)
+func (d *sopsDecryptor) walk(value any, path []string) (any, error) {
+	switch v := value.(type) {
+	case yaml.MapSlice:
+		for i, item := range v {
+			decrypted, err := d.walk(item.Value, append(slices.Clip(path), fmt.Sprint(item.Key)))
+			if err != nil {
+				return nil, err
+			}
+			v[i].Value = decrypted
+		}
+		return v, nil
+	case []any:
+		// list items don't add to the path
+		for i, item := range v {
+			decrypted, err := d.walk(item, path)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = decrypted
+		}
+		return v, nil
+	case nil:
+		return nil, nil
+	case string:
+		if sopsValueRegex.MatchString(v) {
+			pathString := strings.Join(path, ":") + ":"
+			plaintext, err := d.decrypt(v, pathString)
+			if err != nil {
+				return nil, fmt.Errorf("could not decrypt value at %s %v", pathString, err)
+			}
+			d.mac.Write([]byte(plaintext))
+			return sopsTypedValue(plaintext, sopsValueRegex.FindStringSubmatch(v)[4], pathString)
+		}
+	}
+	if !d.macOnlyEncrypted {
+		d.mac.Write([]byte(sopsMACValue(value)))
+	}
+	return value, nil
+}
+
+func (d *sopsDecryptor) decrypt(value, additionalData string) (string, error) {
+	match := sopsValueRegex.FindStringSubmatch(value)
+	if match == nil {
+		return "", errors.New("value is not in the sops format")
+	}
+	var parts [3][]byte
+	for i, encoded := range match[1:4] {
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return "", fmt.Errorf("invalid base64: %v", err)
+		}
+		parts[i] = decoded
+	}
+	data, iv, tag := parts[0], parts[1], parts[2]
+
+	block, err := aes.NewCipher(d.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, append(data, tag...), []byte(additionalData))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// converts the decrypted string back to its original type, the parse errors would quote the value so they're dropped
+func sopsTypedValue(plaintext, valueType, path string) (any, error) {
+	var (
+		value any
+		err   error
+	)
+	switch valueType {
+	case "str", "bytes", "time":
+		value = plaintext
+	case "int":
+		value, err = strconv.Atoi(plaintext)
+	case "float":
+		value, err = strconv.ParseFloat(plaintext, 64)
+	case "bool":
+		value, err = strconv.ParseBool(plaintext)
+	default:
+		return nil, fmt.Errorf("unknown type %q of value at %s", valueType, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("value at %s is not a valid %s", path, valueType)
+	}
+	return value, nil
+}
+
+// the same representation SOPS hashes the values in
+func sopsMACValue(value any) string {
+	switch v := value.(type) {
+	case bool:
+		if v {
+			return "True"
+		}
+		return "False"
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
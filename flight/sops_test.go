@@ -0,0 +1,173 @@
+package flight
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// test-only keys, the fixtures below were encrypted with `sops encrypt --age <recipient> --encrypted-regex '^secrets$'`
+const (
+	sopsTestKey  = "AGE-SECRET-KEY-197DFTVMUFMCQ5XFEUJ2PKC4363VEEJZMT2CTVT7Q24ERSLDNWK0SLX3YQZ"
+	sopsOtherKey = "AGE-SECRET-KEY-1YJ9CCUS3AGPRGVXS8GUG8NRAFK44QC62YR4PDZRJ3TH35JZWNFDSHR2XAN"
+)
+
+const sopsEncryptedValues = `namespace: foo
+service: foo
+component: bar
+environment: test
+image:
+    repository: foo
+    tag: bleh
+replicaCount: 2
+kubeSecrets:
+    app-config: null
+secrets:
+    app-config:
+        API_KEY: ENC[AES256_GCM,data:qEum5Nn/pqp0++8H,iv:F0BXSBAWMbdTXMkp4eXGoQqDkE1CXLO6G855w5guLyo=,tag:yJ7TdKhW4+kCMsgcjm8Qrg==,type:str]
+        PORT: ENC[AES256_GCM,data:q56IkQ==,iv:WJeGZ3i9G9xjq5ixgSbj0k86KVrk4uYTNPucGsOcSSY=,tag:9gJICAuGIQBW75SSpB6Cmw==,type:str]
+sops:
+    age:
+        - recipient: age1lftydxscvumgupns7c3ez9jualce0p7jgze8jqkt7gupm9dv4vks7cewrf
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSA5SnN0S3QwdUFKNmx0bll5
+            WXhScEJPRHdSRkFPRkNobHlqdnR4MDhNU3pVCkxTUndXcmJLb2F0UnZlbzdRVzhw
+            M3lMTzQycUcrTVM5V1dlUHdKa09ORHcKLS0tIFBlbEpZdFhqYVZlT24wWXpNZUlt
+            V1l6cm5rcERiS2d1ME56RXUzeGI0VHMKtdnlSNqwGdLLRwC9iboE5wjQ3FAhuDhY
+            Q3KoCHvFpXC5NulynSWHObzXLFojXJhVqVJsn3CmVuloWWYjMspLXw==
+            -----END AGE ENCRYPTED FILE-----
+    lastmodified: "2026-10-18T18:27:28Z"
+    mac: ENC[AES256_GCM,data:YrIg9d5SKwKEbmGUPHNT2OAq1S6xgzVf80K5WHpaMofiz10E93h/XhVc2RZq1uFxO8hwWTkHS1D/lCiUa7AzHWQdFsNnEYy+ikGycM0QJQMFtz9st/TSnnHLKf/OpWwZnSfpqtqYd1X8T+Fa1/n8yJkLqfnUqxt6QTQB9RrBQIc=,iv:ohp1TJ5tWB/zHygBt8N55kC9dt09JmiBiNiLBsGty8U=,tag:0rGymbtmvu0+T9oMiBh4WQ==,type:str]
+    encrypted_regex: ^secrets$
+    version: 3.10.2
+`
+
+// `secrets` has a nested map, which fails unmarshalling after decryption
+const sopsEncryptedInvalidValues = `namespace: foo
+service: foo
+component: bar
+environment: test
+image:
+    repository: foo
+    tag: bleh
+secrets:
+    app-config:
+        API_KEY:
+            nested: ENC[AES256_GCM,data:h1TSQTknAm8hMQw3,iv:UsOOin0ILybm1EI6D1p+LG5+HEDRc3yfYQQnLMOhT/Y=,tag:TKWsFa7JjJ+MnAqivZeGkg==,type:str]
+sops:
+    age:
+        - recipient: age1lftydxscvumgupns7c3ez9jualce0p7jgze8jqkt7gupm9dv4vks7cewrf
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBVTzZlVDJmNDY4bXhrNFBB
+            azBMQmtOODhBaitWTFJ0anF1alljdUp6SkZVCkxvZ0VPMm5NcmdZM3R0ZzNaMy93
+            ZHNOaWpIdmJRSGRaNHloLzB1c1VGNVUKLS0tIC9KU2x0ZU9jREppVjhuU2NCV2tr
+            N1MvYmpISEVqZlpuWk9IWGlVY3FBa28KHx+kRYatWop0/r6c3UszG5Zk7v2ZkOXK
+            KT43m0uUQcKDtpx4unaa1JvPtNX9crQgSoch01CoB8bBs15LZAv8ng==
+            -----END AGE ENCRYPTED FILE-----
+    lastmodified: "2026-10-18T18:27:28Z"
+    mac: ENC[AES256_GCM,data:eLEPuJ5hkhrZ1tQ+ngqZQgJLl1zRKlA/rYa9u6Kbz6hTW8+q5fNF9UxEy86EgUOYCmF6s421VJlENPmwjEBQkkHzubrAKtnmFHBWveY+vQ2/tkjLTn573P9cOxu51dgllJk+c6GwrSOauuvPq8dvL3E29nvPNQacGte1jIFL6hw=,iv:ZzJApdJx7ukYvgfqwG0D6ElD5p6hmqjxLEGiRXMzTwg=,tag:8RtO6lVHFjIMaiBMTkgAHg==,type:str]
+    encrypted_regex: ^secrets$
+    version: 3.10.2
+`
+
+func TestSops(t *testing.T) {
+	type CaseConfig struct {
+		Input string
+		Env   map[string]string
+		// written to a temporary file passed in SOPS_AGE_KEY_FILE
+		KeyFile string
+		Asserts func(*testing.T, schema.InputValues, error)
+	}
+
+	cases := map[string]CaseConfig{
+		"decrypts values with key from env": {
+			Input: sopsEncryptedValues,
+			Env:   map[string]string{sopsAgeKeyEnv: sopsTestKey},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[string]map[string]string{
+					"app-config": {"API_KEY": "s3cr3t-value", "PORT": "5432"},
+				}, iv.Secrets)
+				assert.Equal(t, "foo", iv.Metadata.Namespace)
+				assert.Equal(t, 2, *iv.ReplicaCount)
+				assert.Contains(t, iv.KubeSecrets, "app-config")
+			},
+		},
+		"decrypts values with key from file": {
+			Input:   sopsEncryptedValues,
+			KeyFile: sopsTestKey + "\n",
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "s3cr3t-value", iv.Secrets["app-config"]["API_KEY"])
+			},
+		},
+		"fails without key": {
+			Input: sopsEncryptedValues,
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "no age key was provided")
+			},
+		},
+		"fails with key of another recipient": {
+			Input: sopsEncryptedValues,
+			Env:   map[string]string{sopsAgeKeyEnv: sopsOtherKey},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "none of the provided age keys can decrypt")
+			},
+		},
+		"fails with invalid key without printing it": {
+			Input: sopsEncryptedValues,
+			Env:   map[string]string{sopsAgeKeyEnv: "AGE-SECRET-KEY-1NOTAKEY"},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "could not parse age key")
+				assert.NotContains(t, err.Error(), "NOTAKEY")
+			},
+		},
+		"fails when unencrypted values were modified": {
+			Input: strings.Replace(sopsEncryptedValues, "replicaCount: 2", "replicaCount: 3", 1),
+			Env:   map[string]string{sopsAgeKeyEnv: sopsTestKey},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "MAC mismatch")
+			},
+		},
+		"fails when encrypted value was moved": {
+			Input: strings.Replace(sopsEncryptedValues, "API_KEY:", "OTHER_KEY:", 1),
+			Env:   map[string]string{sopsAgeKeyEnv: sopsTestKey},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "could not decrypt value at secrets:app-config:OTHER_KEY:")
+			},
+		},
+		"does not print decrypted values on unmarshalling error": {
+			Input: sopsEncryptedInvalidValues,
+			Env:   map[string]string{sopsAgeKeyEnv: sopsTestKey},
+			Asserts: func(t *testing.T, iv schema.InputValues, err error) {
+				assert.ErrorContains(t, err, "unmarshalling error")
+				assert.NotContains(t, err.Error(), "s3cr3t")
+			},
+		},
+	}
+
+	for testName, tc := range cases {
+		t.Run(testName, func(t *testing.T) {
+			t.Setenv(sopsAgeKeyEnv, "")
+			t.Setenv(sopsAgeKeyFileEnv, "")
+			for name, value := range tc.Env {
+				t.Setenv(name, value)
+			}
+			if tc.KeyFile != "" {
+				path := filepath.Join(t.TempDir(), "keys.txt")
+				require.NoError(t, os.WriteFile(path, []byte(tc.KeyFile), 0o600))
+				t.Setenv(sopsAgeKeyFileEnv, path)
+			}
+
+			values, _, err := parseFromSource(strings.NewReader(tc.Input), schema.Release{}, false)
+			tc.Asserts(t, values, err)
+		})
+	}
+}
@@ -1,147 +1,163 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
-	"github.com/ProRocketeers/yoke-chart/resources"
-	"github.com/ProRocketeers/yoke-chart/schema"
-	"github.com/go-playground/validator/v10"
-	yaml "github.com/goccy/go-yaml"
+	"github.com/ProRocketeers/yoke-chart/flight"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime"
-	k8sjson "k8s.io/apimachinery/pkg/runtime/serializer/json"
 )
 
+// returned when `-diff-against` finds differences - exits with 2, so CI can tell it apart from errors (1)
+var errChanged = errors.New("rendered objects changed")
+
 func main() {
 	if err := run(); err != nil {
 		// outputting to `stderr` actually shows the output in ArgoCD CMP plugin, `stdout` gets discarded
 		fmt.Fprintln(os.Stderr, err)
+		if errors.Is(err, errChanged) {
+			os.Exit(2)
+		}
 		os.Exit(1)
 	}
 }
 
 func run() error {
 	file := flag.String("file", "", "read from file instead of stdin (for debugging)")
+	strict := flag.Bool("strict", false, "fail on unknown fields in the values instead of warning about them")
+	diffAgainst := flag.String("diff-against", "", "print the differences to the objects rendered from this values file, or to a saved JSON render, instead of the objects")
+	explain := flag.Bool("explain", false, "annotate the objects with the values paths they're rendered from, and print the fields set by overrides (podSpec, containerSpec...) to stderr")
+	output := flag.String("output", "json", "json array of the objects (what yoke expects), yaml documents, or dir=<path> with a file per object")
+	airway := flag.Bool("airway", false, "output the yoke Airway with a custom resource of the values instead of rendering them")
+	airwayGroup := flag.String("airway-group", "", "API group of the Airway's custom resource")
+	airwayKind := flag.String("airway-kind", "Backend", "kind of the Airway's custom resource")
+	wasmURL := flag.String("wasm-url", "", "URL of this Flight's wasm module, for the Airway")
 	flag.Parse()
 
-	var (
-		values schema.InputValues
-		err    error
-	)
-	if file != nil && *file != "" {
-		f, err := os.Open(*file)
+	if *airway {
+		a, err := flight.BuildAirway(flight.AirwayConfig{Group: *airwayGroup, Kind: *airwayKind, WasmURL: *wasmURL})
 		if err != nil {
-			return fmt.Errorf("error while reading file %v: %v", *file, err)
+			return fmt.Errorf("error while building the Airway: %v", err)
+		}
+		if err := json.NewEncoder(os.Stdout).Encode(a.Object); err != nil {
+			return fmt.Errorf("error while encoding to stdout: %v", err)
 		}
-		values, err = parseFromSource(f)
+		return nil
+	}
+
+	encoding, dir, err := parseOutput(*output)
+	if err != nil {
+		return err
+	}
+
+	var source []byte
+	if file != nil && *file != "" {
+		source, err = os.ReadFile(*file)
 		if err != nil {
-			return fmt.Errorf("error while parsing from file: %v", err)
+			return fmt.Errorf("error while reading file %v: %v", *file, err)
 		}
 	} else {
-		values, err = parseFromSource(os.Stdin)
+		source, err = io.ReadAll(os.Stdin)
 		if err != nil {
-			return fmt.Errorf("error while parsing from stdin: %v", err)
+			return fmt.Errorf("error while reading stdin: %v", err)
 		}
 	}
 
-	deploymentValues, err := resources.PrepareDeploymentValues(values)
-
-	if err != nil {
-		return fmt.Errorf("error while preparing the deployment values: %v", err)
+	opts := flight.Options{
+		Release:  flight.ReleaseFromEnv(),
+		Strict:   *strict,
+		Encoding: encoding,
+		Explain:  *explain,
 	}
-
-	namedResources, err := collectResources(
-		deploymentValues,
-		resources.CreateMainWorkload,
-		resources.CreateService,
-		resources.CreateIngress,
-		resources.CreateHttpRoutes,
-		resources.CreateNetworkPolicies,
-		resources.CreateServiceAccount,
-		resources.CreatePVCs,
-		resources.CreatePreDeploymentJob,
-		resources.CreateCronjobs,
-		resources.CreateExternalSecrets,
-		resources.CreateHPA,
-		resources.CreatePDB,
-		resources.CreateDB,
-		resources.CreateRBAC,
-		resources.CreateConfigMaps,
-		resources.CreatePrometheusMonitors,
-	)
-
+	result, err := render(source, opts)
 	if err != nil {
-		return fmt.Errorf("error while rendering resources: %v", err)
+		return err
 	}
-
-	outputs := resources.BuildOutputs(namedResources)
-
-	extraManifests, err := resources.RenderExtraManifests(deploymentValues, outputs)
-	if err != nil {
-		return fmt.Errorf("error while rendering extra manifests: %v", err)
+	if *explain {
+		fmt.Fprint(os.Stderr, flight.FormatOverrides(result.Overrides))
 	}
 
-	allResources := make([]unstructured.Unstructured, 0, len(namedResources)+len(extraManifests))
-	for _, nr := range namedResources {
-		allResources = append(allResources, nr.Object)
+	if *diffAgainst != "" {
+		return diff(*diffAgainst, result.Objects, opts)
 	}
-	allResources = append(allResources, extraManifests...)
 
-	jsons := []json.RawMessage{}
-	encoder := k8sjson.NewSerializerWithOptions(
-		k8sjson.DefaultMetaFactory, nil, nil, k8sjson.SerializerOptions{Yaml: false, Strict: true},
-	)
-	for _, r := range allResources {
-		bytes, err := runtime.Encode(encoder, &r)
-		if err != nil {
-			return fmt.Errorf("error while serializing resources: %v", err)
+	if dir != "" {
+		if err := flight.WriteDir(dir, result.Objects); err != nil {
+			return fmt.Errorf("error while writing to %s: %v", dir, err)
 		}
-		jsons = append(jsons, bytes)
+		return nil
 	}
-
-	err = json.NewEncoder(os.Stdout).Encode(jsons)
-	if err != nil {
+	if _, err := os.Stdout.Write(result.Encoded); err != nil {
 		return fmt.Errorf("error while encoding to stdout: %v", err)
 	}
 	return nil
 }
 
-func collectResources(values resources.DeploymentValues, creators ...func(resources.DeploymentValues) (bool, resources.ResourceCreator)) ([]resources.NamedResource, error) {
-	all := []resources.NamedResource{}
-	for _, shouldCreateResource := range creators {
-		if ok, create := shouldCreateResource(values); ok {
-			if newResources, err := create(values); err != nil {
-				return nil, err
-			} else {
-				all = append(all, newResources...)
-			}
-		}
-
+func render(source []byte, opts flight.Options) (flight.Result, error) {
+	result, err := flight.Render(context.Background(), source, opts)
+	if err != nil {
+		return flight.Result{}, err
 	}
-	return all, nil
+	for _, warning := range result.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	return result, nil
 }
 
-func parseFromSource(r io.Reader) (schema.InputValues, error) {
-	var values schema.InputValues
-	bytes, err := io.ReadAll(r)
+// diff prints the differences to the old render to stdout - `file` is either the old values, or the old objects encoded
+// as JSON
+func diff(file string, objects []unstructured.Unstructured, opts flight.Options) error {
+	source, err := os.ReadFile(file)
 	if err != nil {
-		return schema.InputValues{}, fmt.Errorf("stdin read error: %v", err)
+		return fmt.Errorf("error while reading file %v: %v", file, err)
 	}
-	if err := yaml.Unmarshal(bytes, &values); err != nil {
-		return schema.InputValues{}, fmt.Errorf("unmarshalling error: %v", err)
+	var old []unstructured.Unstructured
+	if bytes.HasPrefix(bytes.TrimSpace(source), []byte("[")) {
+		old, err = flight.DecodeJSON(source)
+		if err != nil {
+			return fmt.Errorf("error while reading render %v: %v", file, err)
+		}
+	} else {
+		opts.Encoding = ""
+		result, err := render(source, opts)
+		if err != nil {
+			return fmt.Errorf("error while rendering %v: %v", file, err)
+		}
+		old = result.Objects
+	}
+
+	diffs, err := flight.DiffObjects(old, objects)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprint(os.Stdout, flight.FormatDiff(diffs)); err != nil {
+		return fmt.Errorf("error while writing to stdout: %v", err)
 	}
-	// unmarshal doesn't validate fields being required (`string` vs `*string`), just parses the YAML into struct
-	// to validate required fields or others, need the validator package too
-	validate := validator.New(validator.WithRequiredStructEnabled())
-	if err := validate.Struct(values); err != nil {
-		return schema.InputValues{}, fmt.Errorf("validation error: %v", err)
+	if len(diffs) > 0 {
+		return errChanged
 	}
-	if err := schema.CustomValidations(values); err != nil {
-		return schema.InputValues{}, fmt.Errorf("custom validation error: %v", err)
+	return nil
+}
+
+// parseOutput returns the encoding of stdout, or the directory to write the objects to
+func parseOutput(output string) (flight.Encoding, string, error) {
+	switch {
+	case output == string(flight.EncodingJSON) || output == string(flight.EncodingYAML):
+		return flight.Encoding(output), "", nil
+	case strings.HasPrefix(output, "dir="):
+		dir := strings.TrimPrefix(output, "dir=")
+		if dir == "" {
+			return "", "", fmt.Errorf("-output dir= requires a path")
+		}
+		return "", dir, nil
+	default:
+		return "", "", fmt.Errorf("unknown -output '%s', expected json, yaml or dir=<path>", output)
 	}
-	return values, nil
 }
@@ -0,0 +1,139 @@
+package cilium
+
+// -----------------------
+// trimmed down copy of the `cilium.io/v2` CiliumNetworkPolicy types from https://github.com/cilium/cilium/tree/main/pkg/policy/api
+// same reasoning as the `postgresql` package - importing `github.com/cilium/cilium` pulls in the entire agent
+// with all of its dependencies, most of which don't compile into WASM
+// only the parts of the rule we actually expose in values are kept, field names and JSON tags are unchanged
+// -----------------------
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var SchemeGroupVersion = schema.GroupVersion{Group: "cilium.io", Version: "v2"}
+
+// CiliumNetworkPolicy is a Kubernetes third-party resource with an extended version of NetworkPolicy.
+type CiliumNetworkPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	// Spec is the desired Cilium specific rule specification.
+	Spec *Rule `json:"spec,omitempty"`
+}
+
+// Rule is a policy rule which must be applied to all endpoints which match the labels contained in
+// the endpointSelector
+type Rule struct {
+	// EndpointSelector selects all endpoints which should be subject to this rule.
+	EndpointSelector metav1.LabelSelector `json:"endpointSelector"`
+
+	// Ingress is a list of IngressRule which are enforced at ingress.
+	// If omitted or empty, this rule does not apply at ingress.
+	Ingress []IngressRule `json:"ingress,omitempty"`
+
+	// Egress is a list of EgressRule which are enforced at egress.
+	// If omitted or empty, this rule does not apply at egress.
+	Egress []EgressRule `json:"egress,omitempty"`
+
+	// Description is a free form string, it can be used by the creator of the rule to store human
+	// readable explanation of the purpose of this rule.
+	Description string `json:"description,omitempty"`
+}
+
+// IngressRule contains all rule types which can be applied at ingress, i.e. network traffic that
+// originates outside of the endpoint and is entering the endpoint selected by the endpointSelector.
+type IngressRule struct {
+	FromEndpoints []metav1.LabelSelector `json:"fromEndpoints,omitempty"`
+	FromCIDR      []string               `json:"fromCIDR,omitempty"`
+	FromCIDRSet   []CIDRRule             `json:"fromCIDRSet,omitempty" validate:"dive"`
+	FromEntities  []string               `json:"fromEntities,omitempty"`
+
+	// ToPorts is a list of destination ports identified by port number and protocol which the
+	// endpoint subject to the rule is allowed to receive connections on.
+	ToPorts []PortRule `json:"toPorts,omitempty" validate:"dive"`
+}
+
+// EgressRule contains all rule types which can be applied at egress, i.e. network traffic that
+// originates inside the endpoint and exits the endpoint selected by the endpointSelector.
+type EgressRule struct {
+	ToEndpoints []metav1.LabelSelector `json:"toEndpoints,omitempty"`
+	ToCIDR      []string               `json:"toCIDR,omitempty"`
+	ToCIDRSet   []CIDRRule             `json:"toCIDRSet,omitempty" validate:"dive"`
+	ToEntities  []string               `json:"toEntities,omitempty"`
+
+	// ToFQDNs allows egress to the IPs that the given DNS names (or patterns) resolved to, as
+	// observed by Cilium's DNS proxy - a DNS rule (`toPorts[].rules.dns`) must allow the lookup
+	// somewhere for this to ever match anything
+	ToFQDNs []FQDNSelector `json:"toFQDNs,omitempty" validate:"dive"`
+
+	ToPorts []PortRule `json:"toPorts,omitempty" validate:"dive"`
+}
+
+// CIDRRule is a rule that specifies a CIDR prefix to/from which outside communication is allowed,
+// along with an optional list of subnets within that CIDR prefix to/from which outside
+// communication is not allowed.
+type CIDRRule struct {
+	Cidr        string   `json:"cidr" validate:"required"`
+	ExceptCIDRs []string `json:"except,omitempty"`
+}
+
+type FQDNSelector struct {
+	// MatchName matches literal DNS names. A trailing "." is automatically added when missing.
+	MatchName string `json:"matchName,omitempty" validate:"required_without=MatchPattern,excluded_with=MatchPattern"`
+	// MatchPattern allows using wildcards to match DNS names, e.g. `*.stripe.com`.
+	MatchPattern string `json:"matchPattern,omitempty"`
+}
+
+// PortRule is a list of ports/protocol combinations with optional Layer 7 rules which must be met.
+type PortRule struct {
+	Ports []PortProtocol `json:"ports,omitempty" validate:"dive"`
+	Rules *L7Rules       `json:"rules,omitempty"`
+}
+
+// PortProtocol specifies an L4 port with an optional transport protocol
+type PortProtocol struct {
+	// Port can be an L4 port number, or a name in the form of "http" or "http-8080".
+	Port string `json:"port" validate:"required"`
+	// EndPort can only be an L4 port number.
+	EndPort  int32  `json:"endPort,omitempty"`
+	Protocol string `json:"protocol,omitempty" validate:"omitempty,oneof=TCP UDP SCTP ANY"`
+}
+
+// L7Rules is a union of port level rule types. Mixing of different port level rule types is
+// disallowed, so exactly one of the following must be set.
+type L7Rules struct {
+	HTTP []PortRuleHTTP `json:"http,omitempty" validate:"excluded_with=DNS"`
+	DNS  []FQDNSelector `json:"dns,omitempty" validate:"dive"`
+}
+
+// PortRuleHTTP is a list of HTTP protocol constraints. All fields are optional, if all fields are
+// empty or missing, the rule does not have any effect. Path/Method/Host are extended POSIX regexes.
+type PortRuleHTTP struct {
+	Path    string   `json:"path,omitempty"`
+	Method  string   `json:"method,omitempty"`
+	Host    string   `json:"host,omitempty"`
+	Headers []string `json:"headers,omitempty"`
+}
+
+// DeepCopyObject is only here so the type satisfies `runtime.Object`, which is what `toUnstructured`
+// expects - instead of maintaining a generated deep copy for every nested type, the object is copied
+// through a JSON round trip (all fields are plain JSON-serializable data)
+func (in *CiliumNetworkPolicy) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	out := &CiliumNetworkPolicy{}
+	if err := json.Unmarshal(bytes, out); err != nil {
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,41 @@
+package resources
+
+import (
+	"github.com/ProRocketeers/yoke-chart/resources/cilium"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateCiliumNetworkPolicies(values DeploymentValues) (bool, ResourceCreator) {
+	return len(values.CiliumNetworkPolicies) > 0, func(values DeploymentValues) ([]NamedResource, error) {
+		var resources []NamedResource
+		for name, policy := range sortedMap(values.CiliumNetworkPolicies) {
+			cnp := cilium.CiliumNetworkPolicy{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: cilium.SchemeGroupVersion.Identifier(),
+					Kind:       "CiliumNetworkPolicy",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        networkPolicyName(name, values.Metadata),
+					Namespace:   values.Metadata.Namespace,
+					Annotations: policy.Annotations,
+					Labels:      withCommonLabels(policy.Labels, values.Metadata),
+				},
+				Spec: &cilium.Rule{
+					// always bound to the chart's own Pods, same labels the Service/PDB select on
+					EndpointSelector: metav1.LabelSelector{
+						MatchLabels: podSelectorLabels(values),
+					},
+					Ingress:     policy.Ingress,
+					Egress:      policy.Egress,
+					Description: policy.Description,
+				},
+			}
+			u, err := toUnstructured(&cnp)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryCiliumNetworkPolicies, Key: name, Object: u[0]})
+		}
+		return resources, nil
+	}
+}
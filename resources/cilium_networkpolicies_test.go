@@ -0,0 +1,108 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources/cilium"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCiliumNetworkPolicies(t *testing.T) {
+	commonMetadata := Metadata{
+		Namespace:   "ns",
+		Service:     "service",
+		Component:   "component",
+		Environment: "test",
+	}
+
+	t.Run("does not render anything without policies", func(t *testing.T) {
+		shouldCreate, _ := CreateCiliumNetworkPolicies(DeploymentValues{Metadata: commonMetadata})
+		assert.False(t, shouldCreate)
+	})
+
+	t.Run("binds the endpoint selector to the chart's app label", func(t *testing.T) {
+		values := DeploymentValues{
+			Metadata: commonMetadata,
+			CiliumNetworkPolicies: map[string]schema.CiliumNetworkPolicy{
+				"stripe": {
+					Labels: map[string]string{"team": "payments"},
+					Egress: []cilium.EgressRule{
+						{
+							ToFQDNs: []cilium.FQDNSelector{{MatchName: "api.stripe.com"}},
+							ToPorts: []cilium.PortRule{
+								{Ports: []cilium.PortProtocol{{Port: "443", Protocol: "TCP"}}},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		shouldCreate, createFn := CreateCiliumNetworkPolicies(values)
+		require.True(t, shouldCreate)
+
+		resources, err := createFn(values)
+		require.NoError(t, err)
+		require.Len(t, resources, 1)
+
+		cnp := findResourceOrFail[*cilium.CiliumNetworkPolicy](t, resources, "CiliumNetworkPolicy", "service--component--test-stripe")
+
+		assert.Equal(t, "cilium.io/v2", resources[0].Object.GetAPIVersion())
+		assert.Equal(t, "ns", cnp.Namespace)
+		assert.Subset(t, cnp.Labels, map[string]string{"team": "payments", "app": "service--component--test"})
+		assert.Equal(t, map[string]string{"app": "service--component--test"}, cnp.Spec.EndpointSelector.MatchLabels)
+		assert.Equal(t, "api.stripe.com", cnp.Spec.Egress[0].ToFQDNs[0].MatchName)
+		assert.Equal(t, "443", cnp.Spec.Egress[0].ToPorts[0].Ports[0].Port)
+	})
+
+	t.Run("renders L7 HTTP rules", func(t *testing.T) {
+		values := DeploymentValues{
+			Metadata: commonMetadata,
+			CiliumNetworkPolicies: map[string]schema.CiliumNetworkPolicy{
+				"read-only-api": {
+					Ingress: []cilium.IngressRule{
+						{
+							ToPorts: []cilium.PortRule{
+								{
+									Ports: []cilium.PortProtocol{{Port: "8080", Protocol: "TCP"}},
+									Rules: &cilium.L7Rules{
+										HTTP: []cilium.PortRuleHTTP{{Method: "GET", Path: "/api/.*"}},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+
+		_, createFn := CreateCiliumNetworkPolicies(values)
+		resources, err := createFn(values)
+		require.NoError(t, err)
+
+		cnp := fromUnstructuredOrPanic[*cilium.CiliumNetworkPolicy](resources[0])
+		require.NotNil(t, cnp.Spec.Ingress[0].ToPorts[0].Rules)
+		assert.Equal(t, []cilium.PortRuleHTTP{{Method: "GET", Path: "/api/.*"}}, cnp.Spec.Ingress[0].ToPorts[0].Rules.HTTP)
+	})
+
+	t.Run("renders multiple policies, keyed by name", func(t *testing.T) {
+		values := DeploymentValues{
+			Metadata: commonMetadata,
+			CiliumNetworkPolicies: map[string]schema.CiliumNetworkPolicy{
+				"one": {Description: "first"},
+				"two": {Description: "second"},
+			},
+		}
+
+		_, createFn := CreateCiliumNetworkPolicies(values)
+		resources, err := createFn(values)
+		require.NoError(t, err)
+		require.Len(t, resources, 2)
+
+		assert.Equal(t, "one", resources[0].Key)
+		assert.Equal(t, "two", resources[1].Key)
+		assert.Equal(t, CategoryCiliumNetworkPolicies, resources[0].Category)
+	})
+}
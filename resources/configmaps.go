@@ -1,8 +1,6 @@
 package resources
 
 import (
-	"fmt"
-
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -10,14 +8,14 @@ import (
 func CreateConfigMaps(values DeploymentValues) (bool, ResourceCreator) {
 	return len(values.ConfigMaps) > 0, func(values DeploymentValues) ([]NamedResource, error) {
 		resources := []NamedResource{}
-		for name, contents := range values.ConfigMaps {
+		for name, contents := range sortedMap(values.ConfigMaps) {
 			cm := corev1.ConfigMap{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: corev1.SchemeGroupVersion.Identifier(),
 					Kind:       "ConfigMap",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s", serviceName(values.Metadata), name),
+					Name:      configMapName(name, values.Metadata),
 					Namespace: values.Metadata.Namespace,
 					Labels:    commonLabels(values.Metadata),
 				},
@@ -3,13 +3,12 @@ package resources
 import (
 	"fmt"
 
-	"dario.cat/mergo"
 	"github.com/ProRocketeers/yoke-chart/schema"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 )
 
-func createContainer(c Container, podValues PodValues) (corev1.Container, error) {
+func createContainer(c Container, podValues PodValues, explanation *Explanation) (corev1.Container, error) {
 	envs, envFrom := getEnvs(c, podValues.Metadata)
 	container := corev1.Container{
 		Name:            c.Name,
@@ -34,7 +33,7 @@ func createContainer(c Container, podValues PodValues) (corev1.Container, error)
 	}
 
 	if c.ContainerSpec != nil {
-		if err := mergo.Merge(&container, *c.ContainerSpec, mergo.WithOverride); err != nil {
+		if err := explanation.merge(&container, *c.ContainerSpec, joinPath(c.ValuesPath, "containerSpec")); err != nil {
 			return corev1.Container{}, fmt.Errorf("merging raw containerSpec for container '%v': %v", c.Name, err)
 		}
 	}
@@ -52,8 +51,16 @@ func getEnvs(c Container, metadata Metadata) ([]corev1.EnvVar, []corev1.EnvFromS
 	}
 	envsFrom := []corev1.EnvFromSource{}
 	for secretName, secretMapping := range sortedMap(c.KubeSecrets) {
-		// when mounting the whole secret
-		if secretMapping == nil {
+		secretEnvs, secretEnvsFrom := secretRefEnvs(secretName, secretMapping)
+		envs = append(envs, secretEnvs...)
+		envsFrom = append(envsFrom, secretEnvsFrom...)
+	}
+	for _, definition := range c.ExternalSecrets {
+		// mounted as files (see `addExternalSecretVolumes`) or not consumed at all
+		if definition.As == "volume" || definition.As == "none" {
+			continue
+		}
+		for _, secretName := range externalSecretNames(definition, metadata) {
 			envsFrom = append(envsFrom, corev1.EnvFromSource{
 				SecretRef: &corev1.SecretEnvSource{
 					LocalObjectReference: corev1.LocalObjectReference{
@@ -61,39 +68,48 @@ func getEnvs(c Container, metadata Metadata) ([]corev1.EnvVar, []corev1.EnvFromS
 					},
 				},
 			})
-		} else {
-			for envName, secretKey := range sortedMap(secretMapping) {
-				env := corev1.EnvVar{
-					Name: envName,
-					ValueFrom: &corev1.EnvVarSource{
-						SecretKeyRef: &corev1.SecretKeySelector{
-							LocalObjectReference: corev1.LocalObjectReference{
-								Name: secretName,
-							},
-							Key: envName,
-						},
-					},
-				}
-				if secretKey != nil {
-					env.ValueFrom.SecretKeyRef.Key = *secretKey
-				}
-				envs = append(envs, env)
-			}
 		}
 	}
-	for _, definition := range c.ExternalSecrets {
-		for secretPath := range sortedMap(definition.Mapping) {
-			envsFrom = append(envsFrom, corev1.EnvFromSource{
-				SecretRef: &corev1.SecretEnvSource{
+	for _, definition := range c.SealedSecrets {
+		secretEnvs, secretEnvsFrom := secretRefEnvs(sealedSecretName(definition, metadata), definition.Mapping)
+		envs = append(envs, secretEnvs...)
+		envsFrom = append(envsFrom, secretEnvsFrom...)
+	}
+	envs = append(envs, c.EnvsRaw...)
+	return envs, envsFrom
+}
+
+// the whole secret through `envFrom` without a mapping, otherwise env name => secret key (same as the env name when nil)
+func secretRefEnvs(secretName string, secretMapping schema.SecretMapping) ([]corev1.EnvVar, []corev1.EnvFromSource) {
+	// when mounting the whole secret
+	if secretMapping == nil {
+		return nil, []corev1.EnvFromSource{{
+			SecretRef: &corev1.SecretEnvSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: secretName,
+				},
+			},
+		}}
+	}
+	envs := []corev1.EnvVar{}
+	for envName, secretKey := range sortedMap(secretMapping) {
+		env := corev1.EnvVar{
+			Name: envName,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: secretName(secretPath, definition.SecretStore.Name, metadata),
+						Name: secretName,
 					},
+					Key: envName,
 				},
-			})
+			},
+		}
+		if secretKey != nil {
+			env.ValueFrom.SecretKeyRef.Key = *secretKey
 		}
+		envs = append(envs, env)
 	}
-	envs = append(envs, c.EnvsRaw...)
-	return envs, envsFrom
+	return envs, nil
 }
 
 func getPorts(c Container) []corev1.ContainerPort {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"maps"
 
-	"dario.cat/mergo"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,9 +24,15 @@ func CreateCronjobs(values DeploymentValues) (bool, ResourceCreator) {
 			jobSpec := batchv1.JobSpec{
 				Template: corev1.PodTemplateSpec{
 					ObjectMeta: metav1.ObjectMeta{
-						Annotations: c.PodAnnotations,
+						Annotations: func() map[string]string {
+							m := map[string]string{}
+							maps.Copy(m, istioPodAnnotations(values, true))
+							maps.Copy(m, c.PodAnnotations)
+							return m
+						}(),
 						Labels: func() map[string]string {
 							m := map[string]string{}
+							maps.Copy(m, istioPodLabels(values))
 							maps.Copy(m, c.PodLabels)
 							if c.PodMonitor != nil && *c.PodMonitor.Enabled {
 								m["app"] = cronjobName(c)
@@ -40,7 +45,7 @@ func CreateCronjobs(values DeploymentValues) (bool, ResourceCreator) {
 				},
 			}
 			if c.JobSpec != nil {
-				if err := mergo.Merge(&jobSpec, *c.JobSpec, mergo.WithOverride); err != nil {
+				if err := values.Explanation.merge(&jobSpec, *c.JobSpec, joinPath(c.ValuesPath, "jobSpec")); err != nil {
 					return nil, fmt.Errorf("merging raw jobSpec for cronjob '%v': %v", c.Name, err)
 				}
 			}
@@ -57,7 +62,7 @@ func CreateCronjobs(values DeploymentValues) (bool, ResourceCreator) {
 				ConcurrencyPolicy: batchv1.AllowConcurrent,
 			}
 			if c.CronJobSpec != nil {
-				if err := mergo.Merge(&cronJobSpec, *c.CronJobSpec, mergo.WithOverride); err != nil {
+				if err := values.Explanation.merge(&cronJobSpec, *c.CronJobSpec, joinPath(c.ValuesPath, "cronJobSpec")); err != nil {
 					return nil, fmt.Errorf("merging raw cronJobSpec for cronjob '%v': %v", c.Name, err)
 				}
 			}
@@ -0,0 +1,169 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateDashboards(values DeploymentValues) (bool, ResourceCreator) {
+	return len(values.Dashboards) > 0, func(values DeploymentValues) ([]NamedResource, error) {
+		resources := []NamedResource{}
+		for name, dashboard := range sortedMap(values.Dashboards) {
+			var (
+				contents []byte
+				err      error
+			)
+			if dashboard.Builtin != nil {
+				contents, err = json.Marshal(builtinDashboard(name, *dashboard.Builtin, values))
+			} else {
+				contents, err = normalizeDashboardJSON(dashboard.JSON)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("dashboard %q: %v", name, err)
+			}
+
+			annotations := map[string]string{}
+			if dashboard.Folder != "" {
+				annotations["grafana_folder"] = dashboard.Folder
+			}
+			maps.Copy(annotations, dashboard.Annotations)
+
+			// the Grafana sidecar picks up every ConfigMap with this label
+			labels := map[string]string{"grafana_dashboard": "1"}
+			maps.Copy(labels, dashboard.Labels)
+
+			cm := corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: corev1.SchemeGroupVersion.Identifier(),
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        dashboardName(name, values.Metadata),
+					Namespace:   values.Metadata.Namespace,
+					Annotations: annotations,
+					Labels:      withCommonLabels(labels, values.Metadata),
+				},
+				Data: map[string]string{
+					fmt.Sprintf("%s-%s.json", serviceName(values.Metadata), name): string(contents),
+				},
+			}
+			u, err := toUnstructured(&cm)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryDashboards, Key: name, Object: u[0]})
+		}
+		return resources, nil
+	}
+}
+
+// normalizeDashboardJSON re-encodes the dashboard with sorted keys and no whitespace, so formatting changes of the input
+// don't show up as a diff of the ConfigMap
+func normalizeDashboardJSON(raw string) ([]byte, error) {
+	var dashboard any
+	if err := json.Unmarshal([]byte(raw), &dashboard); err != nil {
+		return nil, fmt.Errorf("parsing dashboard JSON: %v", err)
+	}
+	return json.Marshal(dashboard)
+}
+
+// builtinDashboard is the workload overview - CPU/memory per container, restarts, HPA replicas (with `autoscaling`) and
+// request rate (with `serviceMonitor`). Everything is derived from values only, so the output is stable between renders
+func builtinDashboard(name string, config schema.BuiltinDashboard, values DeploymentValues) map[string]any {
+	ns := values.Metadata.Namespace
+	workload := serviceName(values.Metadata)
+	podSelector := fmt.Sprintf(`namespace=%q, pod=~%q`, ns, workloadPodPattern(values))
+
+	type target struct{ expr, legend string }
+	panels := []map[string]any{}
+	addPanel := func(title, unit string, targets ...target) {
+		i := len(panels)
+		t := []map[string]any{}
+		for j, target := range targets {
+			t = append(t, map[string]any{
+				"refId":        string(rune('A' + j)),
+				"expr":         target.expr,
+				"legendFormat": target.legend,
+				"datasource":   map[string]any{"type": "prometheus", "uid": "${datasource}"},
+			})
+		}
+		panels = append(panels, map[string]any{
+			"id":         i + 1,
+			"type":       "timeseries",
+			"title":      title,
+			"datasource": map[string]any{"type": "prometheus", "uid": "${datasource}"},
+			"gridPos":    map[string]any{"h": 8, "w": 12, "x": (i % 2) * 12, "y": (i / 2) * 8},
+			"fieldConfig": map[string]any{
+				"defaults":  map[string]any{"unit": unit},
+				"overrides": []any{},
+			},
+			"targets": t,
+		})
+	}
+
+	addPanel("CPU usage per container", "short", target{
+		expr:   fmt.Sprintf(`sum by (container) (rate(container_cpu_usage_seconds_total{%s, container!=""}[$__rate_interval]))`, podSelector),
+		legend: "{{container}}",
+	})
+	addPanel("Memory usage per container", "bytes", target{
+		expr:   fmt.Sprintf(`sum by (container) (container_memory_working_set_bytes{%s, container!=""})`, podSelector),
+		legend: "{{container}}",
+	})
+	addPanel("Container restarts", "short", target{
+		expr:   fmt.Sprintf(`sum by (pod, container) (increase(kube_pod_container_status_restarts_total{%s}[$__rate_interval]))`, podSelector),
+		legend: "{{pod}}/{{container}}",
+	})
+	if values.Autoscaling != nil {
+		hpaSelector := fmt.Sprintf(`namespace=%q, horizontalpodautoscaler=%q`, ns, workload)
+		addPanel("HPA replicas", "short",
+			target{expr: fmt.Sprintf(`kube_horizontalpodautoscaler_status_current_replicas{%s}`, hpaSelector), legend: "current"},
+			target{expr: fmt.Sprintf(`kube_horizontalpodautoscaler_status_desired_replicas{%s}`, hpaSelector), legend: "desired"},
+			target{expr: fmt.Sprintf(`kube_horizontalpodautoscaler_spec_max_replicas{%s}`, hpaSelector), legend: "max"},
+		)
+	}
+	if values.ServiceMonitor != nil && *values.ServiceMonitor.Enabled {
+		metric := "http_requests_total"
+		if config.RequestsMetric != "" {
+			metric = config.RequestsMetric
+		}
+		// ServiceMonitor targets get the Service name as their `job`
+		addPanel("Request rate", "reqps", target{
+			expr:   fmt.Sprintf(`sum(rate(%s{namespace=%q, job=%q}[$__rate_interval]))`, metric, ns, workload),
+			legend: "requests",
+		})
+	}
+
+	return map[string]any{
+		"uid":           dashboardUID(name, values.Metadata),
+		"title":         fmt.Sprintf("%s (%s)", workload, ns),
+		"tags":          []string{values.Metadata.Service, values.Metadata.Component, values.Metadata.Environment},
+		"editable":      false,
+		"schemaVersion": 39,
+		"time":          map[string]any{"from": "now-6h", "to": "now"},
+		"refresh":       "1m",
+		"templating": map[string]any{
+			"list": []any{
+				map[string]any{
+					"name":  "datasource",
+					"label": "Data source",
+					"type":  "datasource",
+					"query": "prometheus",
+				},
+			},
+		},
+		"panels": panels,
+	}
+}
+
+// Grafana limits UIDs to 40 characters, the hash keeps them unique across namespaces/releases and stable between renders
+func dashboardUID(name string, metadata Metadata) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s/%s/%s", metadata.Namespace, serviceName(metadata), name)))
+	return hex.EncodeToString(sum[:])[:20]
+}
@@ -0,0 +1,138 @@
+package resources
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestDashboards(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*DeploymentValues)
+		Asserts         func(*testing.T, []NamedResource)
+	}
+
+	dashboardModel := func(t *testing.T, cm *corev1.ConfigMap) map[string]any {
+		require.Len(t, cm.Data, 1)
+		model := map[string]any{}
+		for _, contents := range cm.Data {
+			require.NoError(t, json.Unmarshal([]byte(contents), &model))
+		}
+		return model
+	}
+	panelTitles := func(model map[string]any) []string {
+		titles := []string{}
+		for _, panel := range model["panels"].([]any) {
+			titles = append(titles, panel.(map[string]any)["title"].(string))
+		}
+		return titles
+	}
+
+	cases := map[string]CaseConfig{
+		"renders a labelled ConfigMap with the built-in dashboard": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Dashboards = map[string]schema.Dashboard{
+					"overview": {Builtin: &schema.BuiltinDashboard{}, Folder: "Team A"},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				assert.Equal(t, CategoryDashboards, r[0].Category)
+				assert.Equal(t, "overview", r[0].Key)
+
+				cm := fromUnstructuredOrPanic[*corev1.ConfigMap](r[0])
+				assert.Equal(t, "service--component--test--dashboard-overview", cm.Name)
+				assert.Subset(t, cm.Labels, map[string]string{"grafana_dashboard": "1", "app": "service--component--test"})
+				assert.Equal(t, "Team A", cm.Annotations["grafana_folder"])
+				assert.Contains(t, cm.Data, "service--component--test-overview.json")
+
+				model := dashboardModel(t, cm)
+				assert.Len(t, model["uid"], 20)
+				assert.Equal(t, []string{"CPU usage per container", "Memory usage per container", "Container restarts"}, panelTitles(model))
+
+				cpu := model["panels"].([]any)[0].(map[string]any)["targets"].([]any)[0].(map[string]any)
+				assert.Contains(t, cpu["expr"], `namespace="ns", pod=~"service--component--test-[a-z0-9]+-[a-z0-9]+"`)
+			},
+		},
+		"adds HPA and request rate panels when autoscaling/service monitor are configured": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Autoscaling = &schema.HorizontalPodAutoscaler{MaxReplicas: 5}
+				dv.ServiceMonitor = &schema.ServiceMonitor{Enabled: ptr.To(true), Endpoints: []monitoringv1.Endpoint{{Port: "main-port"}}}
+				dv.Dashboards = map[string]schema.Dashboard{
+					"overview": {Builtin: &schema.BuiltinDashboard{RequestsMetric: "requests_total"}},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				model := dashboardModel(t, fromUnstructuredOrPanic[*corev1.ConfigMap](r[0]))
+				titles := panelTitles(model)
+				assert.Equal(t, []string{"CPU usage per container", "Memory usage per container", "Container restarts", "HPA replicas", "Request rate"}, titles)
+
+				requests := model["panels"].([]any)[4].(map[string]any)["targets"].([]any)[0].(map[string]any)
+				assert.Equal(t, `sum(rate(requests_total{namespace="ns", job="service--component--test"}[$__rate_interval]))`, requests["expr"])
+			},
+		},
+		"normalizes user supplied dashboard JSON": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Dashboards = map[string]schema.Dashboard{
+					"custom": {
+						JSON:   "{\n  \"uid\": \"custom\",\n  \"title\": \"Custom\"\n}",
+						Labels: map[string]string{"grafana_dashboard": "2"},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				cm := fromUnstructuredOrPanic[*corev1.ConfigMap](r[0])
+				assert.Equal(t, `{"title":"Custom","uid":"custom"}`, cm.Data["service--component--test-custom.json"])
+				assert.Equal(t, "2", cm.Labels["grafana_dashboard"])
+			},
+		},
+	}
+
+	base := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Kind: "Deployment",
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := base
+			config.ValuesTransform(&values)
+
+			shouldCreate, create := CreateDashboards(values)
+			require.True(t, shouldCreate)
+
+			resources, err := create(values)
+			require.NoError(t, err)
+
+			config.Asserts(t, resources)
+		})
+	}
+
+	t.Run("renders identical output every time", func(t *testing.T) {
+		values := base
+		values.Autoscaling = &schema.HorizontalPodAutoscaler{MaxReplicas: 5}
+		values.Dashboards = map[string]schema.Dashboard{
+			"a": {Builtin: &schema.BuiltinDashboard{}},
+			"b": {JSON: `{"b": 1, "a": 2}`},
+		}
+
+		_, create := CreateDashboards(values)
+		first, err := create(values)
+		require.NoError(t, err)
+		for range 5 {
+			again, err := create(values)
+			require.NoError(t, err)
+			assert.Equal(t, first, again)
+		}
+	})
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"dario.cat/mergo"
 	postgres "github.com/ProRocketeers/yoke-chart/resources/postgresql"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
@@ -44,7 +43,7 @@ func CreateDB(values DeploymentValues) (bool, ResourceCreator) {
 		}
 
 		if db.AdditionalConfig != nil {
-			if err := mergo.Merge(&spec, *db.AdditionalConfig, mergo.WithOverride); err != nil {
+			if err := values.Explanation.merge(&spec, *db.AdditionalConfig, "db.additionalConfig"); err != nil {
 				return nil, fmt.Errorf("error while merging additional DB config: %v", err)
 			}
 		}
@@ -57,6 +56,7 @@ func CreateDB(values DeploymentValues) (bool, ResourceCreator) {
 			ObjectMeta: metav1.ObjectMeta{
 				Name:      db.ClusterName,
 				Namespace: values.Metadata.Namespace,
+				Labels:    commonLabels(values.Metadata),
 			},
 			Spec: spec,
 		}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"maps"
 
-	"dario.cat/mergo"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,8 +13,17 @@ import (
 func CreateDeployment(values DeploymentValues) (bool, ResourceCreator) {
 	return true, func(values DeploymentValues) ([]NamedResource, error) {
 		podAnnotations := map[string]string{}
+		maps.Copy(podAnnotations, istioPodAnnotations(values, false))
+		maps.Copy(podAnnotations, otelPodAnnotations(values))
 		maps.Copy(podAnnotations, values.PodAnnotations)
 
+		podLabels := map[string]string{}
+		maps.Copy(podLabels, istioPodLabels(values))
+		maps.Copy(podLabels, values.PodLabels)
+		if values.PodMonitor != nil && *values.PodMonitor.Enabled {
+			podLabels["prometheus-scrape"] = "true"
+		}
+
 		for _, container := range values.Containers {
 			podAnnotations["container-"+container.Name+"-image-tag"] = *container.Image.Tag
 		}
@@ -38,14 +46,12 @@ func CreateDeployment(values DeploymentValues) (bool, ResourceCreator) {
 			},
 			Spec: appsv1.DeploymentSpec{
 				Selector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
-						"app": serviceName(values.Metadata),
-					},
+					MatchLabels: workloadSelectorLabels(values.Metadata),
 				},
 				Template: corev1.PodTemplateSpec{
 					ObjectMeta: metav1.ObjectMeta{
 						Annotations: podAnnotations,
-						Labels:      withCommonLabels(values.PodLabels, values.Metadata),
+						Labels:      withCommonLabels(podLabels, values.Metadata),
 					},
 					Spec: podSpec,
 				},
@@ -59,7 +65,7 @@ func CreateDeployment(values DeploymentValues) (bool, ResourceCreator) {
 		}
 
 		if values.DeploymentSpec != nil {
-			if err := mergo.Merge(&deployment.Spec, *values.DeploymentSpec, mergo.WithOverride); err != nil {
+			if err := values.Explanation.merge(&deployment.Spec, *values.DeploymentSpec, "deploymentSpec"); err != nil {
 				return nil, fmt.Errorf("merging raw deploymentSpec: %v", err)
 			}
 		}
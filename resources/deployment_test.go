@@ -597,6 +597,16 @@ func TestDeployment(t *testing.T) {
 				},
 			}
 		},
+		"renders scrape pod label if pod monitor is enabled": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(dv *DeploymentValues) {
+					dv.PodMonitor = &schema.PodMonitor{Enabled: ptr.To(true), Ports: []string{"main-port"}}
+				},
+				Asserts: func(t *testing.T, d *appsv1.Deployment) {
+					assert.Equal(t, "true", d.Spec.Template.Labels["prometheus-scrape"])
+				},
+			}
+		},
 		"automatically uses created service account": func() CaseConfig {
 			return CaseConfig{
 				ValuesTransform: func(dv *DeploymentValues) {},
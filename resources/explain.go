@@ -0,0 +1,335 @@
+package resources
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"dario.cat/mergo"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// annotation with the values paths an object was rendered from, comma-separated
+const ValuesPathsAnnotation = "yoke-flight-values-paths"
+
+// Explanation collects the fields set by the overrides, merged over what the chart generates with `mergo.WithOverride`
+type Explanation struct {
+	Overrides []Override
+}
+
+// Override is a field of a generated spec which an override changed
+type Override struct {
+	// values path of the override, e.g. `cronjobs[1].jobSpec`
+	Path string
+	// field within the override, e.g. `template.spec.restartPolicy` - lists are replaced as a whole
+	Field string
+	// the chart's value, nil when the chart doesn't set the field
+	Default interface{}
+	Value   interface{}
+}
+
+// merge merges the override into the generated spec, recording the fields it changed - `e` is nil unless explaining
+func (e *Explanation) merge(dst, src interface{}, path string) error {
+	if e == nil {
+		return mergo.Merge(dst, src, mergo.WithOverride)
+	}
+	before, err := runtime.DefaultUnstructuredConverter.ToUnstructured(dst)
+	if err != nil {
+		return fmt.Errorf("explaining %s: %v", path, err)
+	}
+	if err := mergo.Merge(dst, src, mergo.WithOverride); err != nil {
+		return err
+	}
+	after, err := runtime.DefaultUnstructuredConverter.ToUnstructured(dst)
+	if err != nil {
+		return fmt.Errorf("explaining %s: %v", path, err)
+	}
+	e.Overrides = append(e.Overrides, changedFields(path, "", before, after)...)
+	return nil
+}
+
+func changedFields(path, prefix string, before, after map[string]interface{}) []Override {
+	keys := []string{}
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+
+	overrides := []Override{}
+	for _, key := range keys {
+		field := fieldPath(prefix, key)
+		oldValue, newValue := before[key], after[key]
+		oldMap, oldIsMap := oldValue.(map[string]interface{})
+		newMap, newIsMap := newValue.(map[string]interface{})
+		if oldIsMap && newIsMap {
+			overrides = append(overrides, changedFields(path, field, oldMap, newMap)...)
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			overrides = append(overrides, Override{Path: path, Field: field, Default: oldValue, Value: newValue})
+		}
+	}
+	return overrides
+}
+
+// keys such as labels can have dots in them
+func fieldPath(prefix, key string) string {
+	if strings.Contains(key, ".") {
+		return fmt.Sprintf("%s[%q]", prefix, key)
+	}
+	return joinPath(prefix, key)
+}
+
+func joinPath(prefix, field string) string {
+	if prefix == "" {
+		return field
+	}
+	return prefix + "." + field
+}
+
+// AnnotateValuesPaths annotates the objects with the values paths they were rendered from, e.g. `cronjobs[1]` or
+// `volumes.data` - metadata like `service` or `namespace`, used by every object, is left out
+func AnnotateValuesPaths(input schema.InputValues, values DeploymentValues, resources []NamedResource, extraManifests []unstructured.Unstructured) {
+	for i := range resources {
+		paths := ValuesPaths(input, values, resources[i])
+		if len(values.GlobalLabels) > 0 {
+			paths = append(paths, "globalLabels")
+		}
+		if len(values.GlobalAnnotations) > 0 {
+			paths = append(paths, "globalAnnotations")
+		}
+		setValuesPaths(&resources[i].Object, paths)
+	}
+	for i := range extraManifests {
+		setValuesPaths(&extraManifests[i], []string{fmt.Sprintf("extraManifests[%d]", i)})
+	}
+}
+
+// objects rendered only from the metadata, like the ServiceAccount by default, aren't annotated
+func setValuesPaths(object *unstructured.Unstructured, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	slices.Sort(paths)
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[ValuesPathsAnnotation] = strings.Join(slices.Compact(paths), ",")
+	object.SetAnnotations(annotations)
+}
+
+// ValuesPaths returns the values paths the resource was rendered from, based on its category and key
+func ValuesPaths(input schema.InputValues, values DeploymentValues, r NamedResource) []string {
+	switch r.Category {
+	case CategoryWorkload:
+		return workloadValuesPaths(input)
+	case CategoryHeadlessService:
+		return append([]string{"kind"}, portsValuesPaths(input)...)
+	case CategoryService:
+		if r.Key != "" {
+			return []string{"services." + r.Key}
+		}
+		return append(setPaths(map[string]bool{"serviceConfig": input.ServiceConfig != nil}), portsValuesPaths(input)...)
+	case CategoryIngress:
+		return []string{"ingress"}
+	case CategoryHTTPRoutes:
+		if input.HTTPRoute != nil {
+			return []string{"httpRoute"}
+		}
+		return []string{"httpRoutes." + r.Key}
+	case CategoryNetworkPolicies:
+		return []string{"networkPolicies." + r.Key}
+	case CategoryCiliumNetworkPolicies:
+		return []string{"ciliumNetworkPolicies." + r.Key}
+	case CategoryServiceAccount, CategoryRole, CategoryRoleBinding, CategoryClusterRole, CategoryClusterRoleBinding:
+		return setPaths(map[string]bool{"serviceAccount": input.ServiceAccount != nil})
+	case CategoryPVCs:
+		return volumeValuesPaths(input, func(name string) bool { return pvcName(name, values.Metadata) == r.Key })
+	case CategoryPreDeploymentJob:
+		return append([]string{"preDeploymentJob"}, inheritedTagValuesPaths(input.PreDeploymentJob.Container)...)
+	case CategoryPreDeploymentPodMonitor:
+		return []string{"preDeploymentJob.podMonitor"}
+	case CategoryCronjobs:
+		return cronjobValuesPaths(input, r.Key, "")
+	case CategoryCronjobPodMonitors:
+		return cronjobValuesPaths(input, r.Key, "podMonitor")
+	case CategoryExternalSecrets, CategoryExternalSecretGenerators:
+		paths := []string{}
+		for _, c := range getAllContainers(values) {
+			for i, definition := range c.ExternalSecrets {
+				if slices.Contains(externalSecretNames(definition, values.Metadata), r.Key) {
+					paths = append(paths, joinPath(c.ValuesPath, fmt.Sprintf("externalSecrets[%d]", i)))
+				}
+			}
+		}
+		return paths
+	case CategorySealedSecrets:
+		paths := []string{}
+		for _, c := range getAllContainers(values) {
+			for i, definition := range c.SealedSecrets {
+				if sealedSecretName(definition, values.Metadata) == r.Key {
+					paths = append(paths, joinPath(c.ValuesPath, fmt.Sprintf("sealedSecrets[%d]", i)))
+				}
+			}
+		}
+		return paths
+	case CategoryPushSecrets:
+		return []string{"pushSecrets." + r.Key}
+	case CategoryHPA:
+		return []string{"autoscaling"}
+	case CategoryPDB:
+		return []string{"podDisruptionBudget"}
+	case CategoryDB:
+		return []string{"db"}
+	case CategoryServiceMonitor:
+		return []string{"serviceMonitor"}
+	case CategoryPodMonitor:
+		return []string{"podMonitor"}
+	case CategoryPrometheusRule:
+		return setPaths(map[string]bool{"prometheusRules": input.PrometheusRules != nil, "slos": len(input.SLOs) > 0})
+	case CategoryVirtualService, CategoryDestinationRule, CategoryPeerAuthentication, CategoryAuthorizationPolicy:
+		return []string{"istio"}
+	case CategoryConfigMaps:
+		return []string{"configMaps." + r.Key}
+	case CategorySecrets:
+		return []string{"secrets." + r.Key}
+	case CategoryDashboards:
+		return []string{"dashboards." + r.Key}
+	default:
+		// extensions
+		return []string{"extensions." + string(r.Category)}
+	}
+}
+
+// setPaths returns the paths which are set, sorted
+func setPaths(set map[string]bool) []string {
+	paths := []string{}
+	for path, ok := range sortedMap(set) {
+		if ok {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func workloadValuesPaths(input schema.InputValues) []string {
+	paths := mainContainerValuesPaths(input.Container)
+	for name := range sortedMap(input.Sidecars) {
+		paths = append(paths, "sidecars."+name)
+	}
+	for i := range input.InitContainers {
+		paths = append(paths, fmt.Sprintf("initContainers[%d]", i))
+	}
+	for name := range sortedMap(input.Volumes) {
+		paths = append(paths, "volumes."+name)
+	}
+	paths = append(paths, schedulingValuesPaths(input.SchedulingConfig)...)
+	return append(paths, setPaths(map[string]bool{
+		"mainContainerName": input.MainContainerName != nil,
+		"replicaCount":      input.ReplicaCount != nil,
+		"strategy":          input.Strategy != nil,
+		"kind":              input.Kind != nil,
+		"deploymentSpec":    input.DeploymentSpec != nil,
+		"statefulSetSpec":   input.StatefulSetSpec != nil,
+		"podSpec":           input.PodSpec != nil,
+		"annotations":       len(input.Annotations) > 0,
+		"labels":            len(input.Labels) > 0,
+		"podAnnotations":    len(input.PodAnnotations) > 0,
+		"podLabels":         len(input.PodLabels) > 0,
+		"istio":             input.Istio != nil,
+		"otel":              input.Otel != nil,
+		// the replicas are left to the HPA
+		"autoscaling": input.Autoscaling != nil,
+	})...)
+}
+
+// the main container's fields are top-level, unlike the others' which are rendered from a single path
+func mainContainerValuesPaths(c schema.Container) []string {
+	return setPaths(map[string]bool{
+		"image":           true,
+		"args":            c.Args != nil,
+		"command":         c.Command != nil,
+		"ports":           len(c.Ports) > 0,
+		"envs":            len(c.Envs) > 0,
+		"envsRaw":         len(c.EnvsRaw) > 0,
+		"kubeSecrets":     len(c.KubeSecrets) > 0,
+		"externalSecrets": len(c.ExternalSecrets) > 0,
+		"sealedSecrets":   len(c.SealedSecrets) > 0,
+		"resources":       c.Resources != nil,
+		"readinessProbe":  c.ReadinessProbe != nil,
+		"livenessProbe":   c.LivenessProbe != nil,
+		"startupProbe":    c.StartupProbe != nil,
+		"lifecycle":       c.Lifecycle != nil,
+		"containerSpec":   c.ContainerSpec != nil,
+	})
+}
+
+func schedulingValuesPaths(config schema.SchedulingConfig) []string {
+	return setPaths(map[string]bool{
+		"nodeSelector":              len(config.NodeSelector) > 0,
+		"tolerations":               len(config.Tolerations) > 0,
+		"affinity":                  config.Affinity != nil,
+		"topologySpreadConstraints": len(config.TopologySpreadConstraints) > 0,
+		"priorityClassName":         config.PriorityClassName != nil,
+	})
+}
+
+// ports of the main container and the sidecars, the Services are built from
+func portsValuesPaths(input schema.InputValues) []string {
+	paths := []string{"ports"}
+	for name, sidecar := range sortedMap(input.Sidecars) {
+		if len(sidecar.Ports) > 0 {
+			paths = append(paths, fmt.Sprintf("sidecars.%s.ports", name))
+		}
+	}
+	return paths
+}
+
+// volumes of all the pods, matching the name
+func volumeValuesPaths(input schema.InputValues, match func(name string) bool) []string {
+	paths := []string{}
+	add := func(prefix string, volumes map[string]schema.Volume) {
+		for name := range sortedMap(volumes) {
+			if match(name) {
+				paths = append(paths, joinPath(prefix, "volumes."+name))
+			}
+		}
+	}
+	add("", input.Volumes)
+	if input.PreDeploymentJob != nil {
+		add("preDeploymentJob", input.PreDeploymentJob.Volumes)
+	}
+	for i, cronjob := range input.Cronjobs {
+		add(fmt.Sprintf("cronjobs[%d]", i), cronjob.Volumes)
+	}
+	return paths
+}
+
+// `field` is empty for the CronJob itself
+func cronjobValuesPaths(input schema.InputValues, name, field string) []string {
+	for i, cronjob := range input.Cronjobs {
+		if cronjob.Name != name {
+			continue
+		}
+		path := fmt.Sprintf("cronjobs[%d]", i)
+		if field != "" {
+			return []string{path + "." + field}
+		}
+		return append([]string{path}, inheritedTagValuesPaths(cronjob.Container)...)
+	}
+	return nil
+}
+
+// the tag comes from the main container with `inheritMainContainerTag`
+func inheritedTagValuesPaths(c schema.Container) []string {
+	return setPaths(map[string]bool{"image.tag": c.Image.InheritMainContainerTag != nil && *c.Image.InheritMainContainerTag})
+}
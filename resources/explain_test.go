@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestExplanationMerge(t *testing.T) {
+	chart := func() corev1.Container {
+		return corev1.Container{
+			Name:            "main",
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Env:             []corev1.EnvVar{{Name: "A", Value: "a"}},
+		}
+	}
+	override := corev1.Container{
+		ImagePullPolicy: corev1.PullAlways,
+		Env:             []corev1.EnvVar{{Name: "B", Value: "b"}},
+		SecurityContext: &corev1.SecurityContext{RunAsUser: ptr.To(int64(1000))},
+	}
+
+	t.Run("merges without recording when not explaining", func(t *testing.T) {
+		var explanation *Explanation
+		container := chart()
+		require.NoError(t, explanation.merge(&container, override, "containerSpec"))
+		assert.Equal(t, corev1.PullAlways, container.ImagePullPolicy)
+	})
+
+	t.Run("records the fields the override changed", func(t *testing.T) {
+		explanation := &Explanation{}
+		container := chart()
+		require.NoError(t, explanation.merge(&container, override, "sidecars.proxy.containerSpec"))
+		assert.Equal(t, []Override{
+			{
+				Path:    "sidecars.proxy.containerSpec",
+				Field:   "env",
+				Default: []interface{}{map[string]interface{}{"name": "A", "value": "a"}},
+				Value:   []interface{}{map[string]interface{}{"name": "B", "value": "b"}},
+			},
+			{Path: "sidecars.proxy.containerSpec", Field: "imagePullPolicy", Default: "IfNotPresent", Value: "Always"},
+			{Path: "sidecars.proxy.containerSpec", Field: "securityContext", Value: map[string]interface{}{"runAsUser": int64(1000)}},
+		}, explanation.Overrides)
+	})
+
+	t.Run("quotes keys with dots", func(t *testing.T) {
+		explanation := &Explanation{}
+		podSpec := corev1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/os": "linux"}}
+		override := corev1.PodSpec{NodeSelector: map[string]string{"kubernetes.io/os": "windows"}}
+		require.NoError(t, explanation.merge(&podSpec, override, "podSpec"))
+		assert.Equal(t, []Override{
+			{Path: "podSpec", Field: `nodeSelector["kubernetes.io/os"]`, Default: "linux", Value: "windows"},
+		}, explanation.Overrides)
+	})
+}
@@ -2,13 +2,18 @@ package resources
 
 import (
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
 	"time"
 
+	"github.com/ProRocketeers/yoke-chart/schema"
 	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	gen "github.com/external-secrets/external-secrets/apis/generators/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/utils/ptr"
 )
 
 func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
@@ -23,8 +28,8 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 	return create, func(values DeploymentValues) ([]NamedResource, error) {
 		resources := []NamedResource{}
 		containers := getAllContainers(values)
-		if hasDuplicateExternalSecrets(containers, values.Metadata) {
-			return nil, fmt.Errorf("duplicate external secret paths in multiple containers")
+		if name, ok := duplicateSecretName(containers, values.Metadata); ok {
+			return nil, fmt.Errorf("duplicate external secret paths in multiple containers: %s", name)
 		}
 		for _, container := range containers {
 			for _, definition := range container.ExternalSecrets {
@@ -37,10 +42,12 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 					deletionPolicy = *definition.DeletionPolicy
 				}
 
-				for secretPath, secretMapping := range sortedMap(definition.Mapping) {
-					secretName := secretName(secretPath, definition.SecretStore.Name, values.Metadata)
-
-					secret := es.ExternalSecret{
+				newExternalSecret := func(secretName string, defaultRefreshInterval time.Duration) es.ExternalSecret {
+					refreshInterval := &metav1.Duration{Duration: defaultRefreshInterval}
+					if definition.RefreshInterval != nil {
+						refreshInterval = definition.RefreshInterval
+					}
+					return es.ExternalSecret{
 						TypeMeta: metav1.TypeMeta{
 							APIVersion: es.SchemeGroupVersion.Identifier(),
 							Kind:       "ExternalSecret",
@@ -53,29 +60,46 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 							}, values.Metadata),
 						},
 						Spec: es.ExternalSecretSpec{
-							RefreshInterval: func() *metav1.Duration {
-								if definition.RefreshInterval != nil {
-									return definition.RefreshInterval
-								}
-								return &metav1.Duration{Duration: 1 * time.Minute}
-							}(),
-							SecretStoreRef: definition.SecretStore,
+							RefreshInterval: refreshInterval,
+							SecretStoreRef:  definition.SecretStore,
+							Target: es.ExternalSecretTarget{
+								Name:           secretName,
+								CreationPolicy: creationPolicy,
+								DeletionPolicy: deletionPolicy,
+							},
 						},
 					}
+				}
+
+				if definition.Generate != nil {
+					generated, err := createGeneratedSecret(definition, newExternalSecret, values.Metadata)
+					if err != nil {
+						return nil, fmt.Errorf("container '%v': %v", container.Name, err)
+					}
+					resources = append(resources, generated...)
+					continue
+				}
+
+				for secretPath, secretMapping := range sortedMap(definition.Mapping) {
+					secretName := secretName(secretPath, definition.SecretStore.Name, values.Metadata)
+					secret := newExternalSecret(secretName, 1*time.Minute)
 
 					// fetching the entire secret
 					if secretMapping == nil {
+						decoding, conversion, metadataPolicy := externalSecretStrategies(definition, "")
 						secret.Spec.DataFrom = []es.ExternalSecretDataFromRemoteRef{
 							{
 								Extract: &es.ExternalSecretDataRemoteRef{
-									Key: secretPath,
+									Key:                secretPath,
+									ConversionStrategy: conversion,
+									DecodingStrategy:   decoding,
+									MetadataPolicy:     metadataPolicy,
 								},
 							},
 						}
-						secret.Spec.Target = es.ExternalSecretTarget{
-							Name:           secretName,
-							CreationPolicy: creationPolicy,
-							DeletionPolicy: deletionPolicy,
+						// templated keys are added next to the fetched ones
+						if definition.Template != nil {
+							secret.Spec.Target.Template = externalSecretTemplate(definition.Template, es.MergePolicyMerge, nil)
 						}
 					} else {
 						// or just part of it
@@ -88,13 +112,14 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 								property = *vaultKey
 							}
 
+							decoding, conversion, metadataPolicy := externalSecretStrategies(definition, envName)
 							r := es.ExternalSecretData{
 								RemoteRef: es.ExternalSecretDataRemoteRef{
 									Key:                secretPath,
 									Property:           property,
-									ConversionStrategy: es.ExternalSecretConversionDefault,
-									DecodingStrategy:   es.ExternalSecretDecodeNone,
-									MetadataPolicy:     es.ExternalSecretMetadataPolicyNone,
+									ConversionStrategy: conversion,
+									DecodingStrategy:   decoding,
+									MetadataPolicy:     metadataPolicy,
 								},
 								SecretKey: strings.ToLower(envName),
 							}
@@ -104,17 +129,7 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 						}
 
 						secret.Spec.Data = remoteRefs
-						secret.Spec.Target = es.ExternalSecretTarget{
-							Name:           secretName,
-							CreationPolicy: creationPolicy,
-							DeletionPolicy: deletionPolicy,
-							Template: &es.ExternalSecretTemplate{
-								Type:          corev1.SecretTypeOpaque,
-								EngineVersion: es.TemplateEngineV2,
-								MergePolicy:   es.MergePolicyReplace,
-								Data:          templateData,
-							},
-						}
+						secret.Spec.Target.Template = externalSecretTemplate(definition.Template, es.MergePolicyReplace, templateData)
 					}
 					u, err := toUnstructured(&secret)
 					if err != nil {
@@ -128,6 +143,172 @@ func CreateExternalSecrets(values DeploymentValues) (bool, ResourceCreator) {
 	}
 }
 
+// createGeneratedSecret renders the generator object and an ExternalSecret pulling from it (`sourceRef.generatorRef`)
+// - the generator plays the role of the secret store, so the Secret is named after its kind instead of a store
+func createGeneratedSecret(definition schema.ExternalSecretDefinition, newExternalSecret func(string, time.Duration) es.ExternalSecret, metadata Metadata) ([]NamedResource, error) {
+	generate := definition.Generate
+	name := generatedSecretName(*generate, metadata)
+	objectMeta := metav1.ObjectMeta{
+		Name:      name,
+		Namespace: metadata.Namespace,
+		Labels:    commonLabels(metadata),
+	}
+
+	var (
+		generator runtime.Object
+		kind      string
+		// passwords stay the same until the ExternalSecret is recreated, registry tokens expire and need refreshing
+		refreshInterval time.Duration
+	)
+	switch {
+	case generate.Password != nil && generate.ECRAuthorizationToken == nil && generate.GCRAccessToken == nil:
+		kind = "Password"
+		generator = &gen.Password{TypeMeta: generatorTypeMeta(kind), ObjectMeta: objectMeta, Spec: *generate.Password}
+	case generate.ECRAuthorizationToken != nil && generate.Password == nil && generate.GCRAccessToken == nil:
+		kind, refreshInterval = "ECRAuthorizationToken", time.Hour
+		generator = &gen.ECRAuthorizationToken{TypeMeta: generatorTypeMeta(kind), ObjectMeta: objectMeta, Spec: *generate.ECRAuthorizationToken}
+	case generate.GCRAccessToken != nil && generate.Password == nil && generate.ECRAuthorizationToken == nil:
+		kind, refreshInterval = "GCRAccessToken", 30*time.Minute
+		generator = &gen.GCRAccessToken{TypeMeta: generatorTypeMeta(kind), ObjectMeta: objectMeta, Spec: *generate.GCRAccessToken}
+	default:
+		return nil, fmt.Errorf("generated secret %q: exactly one of `password`, `ecrAuthorizationToken`, `gcrAccessToken` has to be specified", generate.Name)
+	}
+
+	secret := newExternalSecret(name, refreshInterval)
+	secret.Spec.SecretStoreRef = es.SecretStoreRef{}
+	secret.Spec.DataFrom = []es.ExternalSecretDataFromRemoteRef{
+		{
+			SourceRef: &es.StoreGeneratorSourceRef{
+				GeneratorRef: &es.GeneratorRef{
+					APIVersion: gen.SchemeGroupVersion.Identifier(),
+					Kind:       kind,
+					Name:       name,
+				},
+			},
+		},
+	}
+	if generate.Mapping != nil {
+		// only the mapped output keys, renamed to the env names
+		templateData := map[string]string{}
+		for envName, outputKey := range sortedMap(generate.Mapping) {
+			key := envName
+			if outputKey != nil {
+				key = *outputKey
+			}
+			templateData[envName] = fmt.Sprintf("{{ .%s }}", key)
+		}
+		secret.Spec.Target.Template = externalSecretTemplate(definition.Template, es.MergePolicyReplace, templateData)
+	} else if definition.Template != nil {
+		secret.Spec.Target.Template = externalSecretTemplate(definition.Template, es.MergePolicyMerge, nil)
+	}
+
+	u, err := toUnstructured(generator, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return []NamedResource{
+		{Category: CategoryExternalSecretGenerators, Key: name, Object: u[0]},
+		{Category: CategoryExternalSecrets, Key: name, Object: u[1]},
+	}, nil
+}
+
+func generatorTypeMeta(kind string) metav1.TypeMeta {
+	return metav1.TypeMeta{
+		APIVersion: gen.SchemeGroupVersion.Identifier(),
+		Kind:       kind,
+	}
+}
+
+// externalSecretNames lists the names of the Secrets a definition creates
+func externalSecretNames(definition schema.ExternalSecretDefinition, metadata Metadata) []string {
+	if definition.Generate != nil {
+		return []string{generatedSecretName(*definition.Generate, metadata)}
+	}
+	names := []string{}
+	for secretPath := range sortedMap(definition.Mapping) {
+		names = append(names, secretName(secretPath, definition.SecretStore.Name, metadata))
+	}
+	return names
+}
+
+func generatedSecretName(generate schema.ExternalSecretGenerator, metadata Metadata) string {
+	kind := "password"
+	if generate.ECRAuthorizationToken != nil {
+		kind = "ecr-token"
+	} else if generate.GCRAccessToken != nil {
+		kind = "gcr-token"
+	}
+	return secretName(generate.Name, kind, metadata)
+}
+
+// externalSecretStrategies resolves the remote ref strategies of a mapped key (empty for whole secret fetches) -
+// per key config first, then the definition's, then the defaults
+func externalSecretStrategies(definition schema.ExternalSecretDefinition, key string) (es.ExternalSecretDecodingStrategy, es.ExternalSecretConversionStrategy, es.ExternalSecretMetadataPolicy) {
+	decoding, conversion, metadataPolicy := es.ExternalSecretDecodeNone, es.ExternalSecretConversionDefault, es.ExternalSecretMetadataPolicyNone
+	for _, strategies := range []schema.ExternalSecretStrategies{definition.ExternalSecretStrategies, definition.Keys[key]} {
+		if strategies.DecodingStrategy != nil {
+			decoding = *strategies.DecodingStrategy
+		}
+		if strategies.ConversionStrategy != nil {
+			conversion = *strategies.ConversionStrategy
+		}
+		if strategies.MetadataPolicy != nil {
+			metadataPolicy = *strategies.MetadataPolicy
+		}
+	}
+	return decoding, conversion, metadataPolicy
+}
+
+// externalSecretTemplate builds the target template - passthrough `data` of the mapped keys, with the user's
+// templated keys layered on top
+func externalSecretTemplate(config *schema.ExternalSecretTemplate, mergePolicy es.TemplateMergePolicy, data map[string]string) *es.ExternalSecretTemplate {
+	template := &es.ExternalSecretTemplate{
+		Type:          corev1.SecretTypeOpaque,
+		EngineVersion: es.TemplateEngineV2,
+		MergePolicy:   mergePolicy,
+		Data:          data,
+	}
+	if config == nil {
+		return template
+	}
+	if config.Type != "" {
+		template.Type = config.Type
+	}
+	if len(config.Data) > 0 {
+		if template.Data == nil {
+			template.Data = map[string]string{}
+		}
+		maps.Copy(template.Data, config.Data)
+	}
+	template.Metadata = es.ExternalSecretTemplateMetadata{
+		Annotations: config.Annotations,
+		Labels:      config.Labels,
+	}
+	return template
+}
+
+// same set of containers as `getAllContainers`, but modified in place
+func forEachContainer(values *DeploymentValues, fn func(*Container)) {
+	for i := range values.Containers {
+		fn(&values.Containers[i])
+	}
+	for i := range values.InitContainers {
+		fn(&values.InitContainers[i])
+	}
+	if values.PreDeploymentJob != nil {
+		fn(&values.PreDeploymentJob.Container)
+		for i := range values.PreDeploymentJob.InitContainers {
+			fn(&values.PreDeploymentJob.InitContainers[i])
+		}
+	}
+	for i := range values.Cronjobs {
+		fn(&values.Cronjobs[i].Container)
+		for i2 := range values.Cronjobs[i].InitContainers {
+			fn(&values.Cronjobs[i].InitContainers[i2])
+		}
+	}
+}
+
 func getAllContainers(values DeploymentValues) []Container {
 	allContainers := []Container{}
 	allContainers = append(allContainers, values.Containers...)
@@ -143,18 +324,103 @@ func getAllContainers(values DeploymentValues) []Container {
 	return allContainers
 }
 
-func hasDuplicateExternalSecrets(containers []Container, metadata Metadata) bool {
+// external and sealed secrets share the `{service}--{component}--{env}--{store}--{name}` naming, so they're checked together
+func duplicateSecretName(containers []Container, metadata Metadata) (string, bool) {
 	var usedNames []string
+	for _, container := range containers {
+		names := []string{}
+		for _, definition := range container.ExternalSecrets {
+			names = append(names, externalSecretNames(definition, metadata)...)
+		}
+		for _, definition := range container.SealedSecrets {
+			names = append(names, sealedSecretName(definition, metadata))
+		}
+		for _, secretName := range names {
+			if slices.Contains(usedNames, secretName) {
+				return secretName, true
+			}
+			usedNames = append(usedNames, secretName)
+		}
+	}
+	return "", false
+}
+
+// addExternalSecretVolumes turns `as: volume` external secrets into secret volumes of the Pod they belong to, mounted
+// only into the owning container - from there on they're regular volumes (mount target validation, Pod spec)
+func addExternalSecretVolumes(values *DeploymentValues) error {
+	var err error
+	mainContainers := append(slices.Clone(values.Containers), values.InitContainers...)
+	if values.Volumes, err = withExternalSecretVolumes(values.Volumes, mainContainers, values.Metadata); err != nil {
+		return err
+	}
+	if j := values.PreDeploymentJob; j != nil {
+		containers := append([]Container{j.Container}, j.InitContainers...)
+		if j.Volumes, err = withExternalSecretVolumes(j.Volumes, containers, j.Metadata); err != nil {
+			return fmt.Errorf("pre-deployment job: %v", err)
+		}
+	}
+	for i := range values.Cronjobs {
+		c := &values.Cronjobs[i]
+		containers := append([]Container{c.Container}, c.InitContainers...)
+		if c.Volumes, err = withExternalSecretVolumes(c.Volumes, containers, c.Metadata); err != nil {
+			return fmt.Errorf("cronjob '%v': %v", c.Name, err)
+		}
+	}
+	return nil
+}
+
+func withExternalSecretVolumes(volumes map[string]schema.Volume, containers []Container, metadata Metadata) (map[string]schema.Volume, error) {
+	// copied on first write, the map may be shared with the input
+	ret, copied := volumes, false
 	for _, container := range containers {
 		for _, definition := range container.ExternalSecrets {
-			for path := range definition.Mapping {
-				secretName := secretName(path, definition.SecretStore.Name, metadata)
-				if slices.Contains(usedNames, secretName) {
-					return true
+			if definition.As != "volume" {
+				continue
+			}
+			// every secret would end up in the same directory
+			secretNames := externalSecretNames(definition, metadata)
+			if len(secretNames) != 1 {
+				return nil, fmt.Errorf("container '%v': external secret with `as: volume` must map exactly one secret path", container.Name)
+			}
+			for _, secretName := range secretNames {
+				volumeName := externalSecretVolumeName(secretName)
+				if _, exists := ret[volumeName]; exists {
+					return nil, fmt.Errorf("container '%v': volume '%v' of external secret '%v' already exists", container.Name, volumeName, secretName)
+				}
+
+				source := corev1.SecretVolumeSource{
+					SecretName:  secretName,
+					DefaultMode: ptr.To(int32(0444)),
+				}
+				if definition.Volume.DefaultMode != nil {
+					source.DefaultMode = definition.Volume.DefaultMode
+				}
+				for key, file := range sortedMap(definition.Volume.Files) {
+					item := corev1.KeyToPath{Key: key, Path: key, Mode: file.Mode}
+					if file.Path != "" {
+						item.Path = file.Path
+					}
+					source.Items = append(source.Items, item)
+				}
+
+				if !copied {
+					ret, copied = map[string]schema.Volume{}, true
+					maps.Copy(ret, volumes)
+				}
+				ret[volumeName] = schema.Volume{
+					Type: schema.VolumeTypeRaw,
+					Mounts: map[string]schema.VolumeMountList{
+						container.Name: {{ContainerPath: definition.Volume.MountPath}},
+					},
+					Variant: schema.RawVolume{Spec: corev1.VolumeSource{Secret: &source}},
 				}
-				usedNames = append(usedNames, secretName)
 			}
 		}
 	}
-	return false
+	return ret, nil
+}
+
+// volume names are DNS labels, so only 63 characters of the secret name fit
+func externalSecretVolumeName(secretName string) string {
+	return truncateName(secretName, maxLabelNameLength)
 }
@@ -3,12 +3,16 @@ package resources
 import (
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/ProRocketeers/yoke-chart/schema"
 	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	gen "github.com/external-secrets/external-secrets/apis/generators/v1alpha1"
 	"github.com/jinzhu/copier"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
 )
 
@@ -242,6 +246,96 @@ func TestExternalSecrets(t *testing.T) {
 				},
 			}
 		},
+		"renders a templated non-Opaque secret": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(dv *DeploymentValues) {
+					dv.Containers[0].ExternalSecrets = []schema.ExternalSecretDefinition{
+						{
+							SecretStore: es.SecretStoreRef{Name: "vault", Kind: "ClusterSecretStore"},
+							Mapping: map[string]schema.SecretMapping{
+								"db/credentials": {"DB_USER": ptr.To("username"), "DB_PASSWORD": ptr.To("password")},
+							},
+							Template: &schema.ExternalSecretTemplate{
+								Type: corev1.SecretTypeBasicAuth,
+								Data: map[string]string{
+									"DATABASE_URL": "postgres://{{ .db_user }}:{{ .db_password }}@db:5432/app",
+								},
+								Labels: map[string]string{"team": "payments"},
+							},
+						},
+					}
+				},
+				Asserts: func(t *testing.T, secrets []*es.ExternalSecret) {
+					require.Len(t, secrets, 1)
+					template := secrets[0].Spec.Target.Template
+					assert.Equal(t, corev1.SecretTypeBasicAuth, template.Type)
+					assert.Equal(t, es.MergePolicyReplace, template.MergePolicy)
+					assert.Equal(t, map[string]string{
+						"DB_USER":      "{{ .db_user }}",
+						"DB_PASSWORD":  "{{ .db_password }}",
+						"DATABASE_URL": "postgres://{{ .db_user }}:{{ .db_password }}@db:5432/app",
+					}, template.Data)
+					assert.Equal(t, map[string]string{"team": "payments"}, template.Metadata.Labels)
+				},
+			}
+		},
+		"merges templated keys into a whole fetched secret": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(dv *DeploymentValues) {
+					dv.Containers[0].ExternalSecrets = []schema.ExternalSecretDefinition{
+						{
+							SecretStore: es.SecretStoreRef{Name: "vault", Kind: "ClusterSecretStore"},
+							Mapping:     map[string]schema.SecretMapping{"registry": nil},
+							As:          "none",
+							Template: &schema.ExternalSecretTemplate{
+								Type: corev1.SecretTypeDockerConfigJson,
+								Data: map[string]string{".dockerconfigjson": "{{ .config }}"},
+							},
+						},
+					}
+				},
+				Asserts: func(t *testing.T, secrets []*es.ExternalSecret) {
+					require.Len(t, secrets, 1)
+					template := secrets[0].Spec.Target.Template
+					assert.Equal(t, corev1.SecretTypeDockerConfigJson, template.Type)
+					assert.Equal(t, es.MergePolicyMerge, template.MergePolicy)
+					assert.Equal(t, map[string]string{".dockerconfigjson": "{{ .config }}"}, template.Data)
+				},
+			}
+		},
+		"resolves strategies per key": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(dv *DeploymentValues) {
+					dv.Containers[0].ExternalSecrets = []schema.ExternalSecretDefinition{
+						{
+							SecretStore: es.SecretStoreRef{Name: "vault", Kind: "ClusterSecretStore"},
+							Mapping: map[string]schema.SecretMapping{
+								"path/to/secret": {"CERT": nil, "TOKEN": nil},
+							},
+							ExternalSecretStrategies: schema.ExternalSecretStrategies{
+								DecodingStrategy: ptr.To(es.ExternalSecretDecodeAuto),
+							},
+							Keys: map[string]schema.ExternalSecretStrategies{
+								"CERT": {
+									DecodingStrategy: ptr.To(es.ExternalSecretDecodeBase64),
+									MetadataPolicy:   ptr.To(es.ExternalSecretMetadataPolicyFetch),
+								},
+							},
+						},
+					}
+				},
+				Asserts: func(t *testing.T, secrets []*es.ExternalSecret) {
+					require.Len(t, secrets, 1)
+					require.Len(t, secrets[0].Spec.Data, 2)
+					cert, token := secrets[0].Spec.Data[0].RemoteRef, secrets[0].Spec.Data[1].RemoteRef
+					assert.Equal(t, es.ExternalSecretDecodeBase64, cert.DecodingStrategy)
+					assert.Equal(t, es.ExternalSecretMetadataPolicyFetch, cert.MetadataPolicy)
+					assert.Equal(t, es.ExternalSecretConversionDefault, cert.ConversionStrategy)
+					assert.Equal(t, es.ExternalSecretDecodeAuto, token.DecodingStrategy)
+					assert.Equal(t, es.ExternalSecretMetadataPolicyNone, token.MetadataPolicy)
+				},
+			}
+		},
 	}
 
 	base := DeploymentValues{
@@ -339,3 +433,215 @@ func findSecretIndexByName(secrets []*es.ExternalSecret, name string) int {
 		return s.Name == name
 	})
 }
+
+func TestExternalSecretVolumes(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, DeploymentValues, error)
+	}
+
+	tlsSecret := func() schema.ExternalSecretDefinition {
+		return schema.ExternalSecretDefinition{
+			SecretStore: es.SecretStoreRef{Name: "vault", Kind: "ClusterSecretStore"},
+			Mapping:     map[string]schema.SecretMapping{"certs/tls": nil},
+			As:          "volume",
+			Volume: &schema.ExternalSecretVolume{
+				MountPath: "/etc/tls",
+				Files: map[string]schema.ExternalSecretFile{
+					"tls.crt": {},
+					"tls.key": {Path: "private/key.pem", Mode: ptr.To(int32(0400))},
+				},
+			},
+		}
+	}
+
+	cases := map[string]CaseConfig{
+		"mounts the secret into the owning container only": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ExternalSecrets = []schema.ExternalSecretDefinition{tlsSecret()}
+				iv.Sidecars = map[string]schema.Container{
+					"proxy": {Image: schema.Image{Repository: "proxy", Tag: ptr.To("1")}},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				_, createDeployment := CreateDeployment(dv)
+				r, err := createDeployment(dv)
+				require.Nil(t, err)
+				podSpec := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test").Spec.Template.Spec
+
+				require.Len(t, podSpec.Volumes, 1)
+				volume := podSpec.Volumes[0]
+				assert.Equal(t, "service--component--test--vault--certs-tls", volume.Name)
+				assert.Equal(t, "service--component--test--vault--certs-tls", volume.Secret.SecretName)
+				assert.Equal(t, ptr.To(int32(0444)), volume.Secret.DefaultMode)
+				assert.Equal(t, []corev1.KeyToPath{
+					{Key: "tls.crt", Path: "tls.crt"},
+					{Key: "tls.key", Path: "private/key.pem", Mode: ptr.To(int32(0400))},
+				}, volume.Secret.Items)
+
+				main, proxy := podSpec.Containers[0], podSpec.Containers[1]
+				assert.Equal(t, []corev1.VolumeMount{
+					{Name: "service--component--test--vault--certs-tls", MountPath: "/etc/tls", ReadOnly: true},
+				}, main.VolumeMounts)
+				assert.Empty(t, main.EnvFrom)
+				assert.Empty(t, proxy.VolumeMounts)
+			},
+		},
+		"mounts into job Pods": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Cronjobs = []schema.Cronjob{
+					{
+						Name:     "renew",
+						Schedule: "* * * * *",
+						Container: schema.Container{
+							Image:           schema.Image{Repository: "renew", Tag: ptr.To("1")},
+							ExternalSecrets: []schema.ExternalSecretDefinition{tlsSecret()},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Empty(t, dv.Volumes)
+				require.Contains(t, dv.Cronjobs[0].Volumes, "service--component--test--vault--certs-tls")
+				assert.Contains(t, dv.Cronjobs[0].Volumes["service--component--test--vault--certs-tls"].Mounts, "main")
+			},
+		},
+		"env mode is the default": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				secret := tlsSecret()
+				secret.As, secret.Volume = "", nil
+				iv.ExternalSecrets = []schema.ExternalSecretDefinition{secret}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Empty(t, dv.Volumes)
+			},
+		},
+		"as: none is neither an env nor a volume": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				secret := tlsSecret()
+				secret.As, secret.Volume = "none", nil
+				iv.ExternalSecrets = []schema.ExternalSecretDefinition{secret}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Empty(t, dv.Volumes)
+				_, envsFrom := getEnvs(dv.Containers[0], dv.Metadata)
+				assert.Empty(t, envsFrom)
+			},
+		},
+		"fails with more than one secret path": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				secret := tlsSecret()
+				secret.Mapping["certs/ca"] = nil
+				iv.ExternalSecrets = []schema.ExternalSecretDefinition{secret}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.ErrorContains(t, err, "must map exactly one secret path")
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("image_tag"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			config.Asserts(t, deploymentValues, err)
+		})
+	}
+}
+
+func TestGeneratedExternalSecrets(t *testing.T) {
+	values := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Containers: []Container{
+			{
+				Name:  "main",
+				Image: Image{Repository: "image_repository", Tag: ptr.To("image_tag")},
+				ExternalSecrets: []schema.ExternalSecretDefinition{
+					{
+						Generate: &schema.ExternalSecretGenerator{
+							Name:     "session-key",
+							Mapping:  schema.SecretMapping{"SESSION_KEY": ptr.To("password")},
+							Password: &gen.PasswordSpec{Length: 32},
+						},
+					},
+					{
+						Generate: &schema.ExternalSecretGenerator{
+							Name:                  "registry",
+							ECRAuthorizationToken: &gen.ECRAuthorizationTokenSpec{Region: "eu-west-1"},
+						},
+						As: "none",
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("renders the generators and ExternalSecrets pulling from them", func(t *testing.T) {
+		_, create := CreateExternalSecrets(values)
+		r, err := create(values)
+		require.Nil(t, err)
+		require.Len(t, r, 4)
+
+		password := findResourceOrFail[*gen.Password](t, r, "Password", "service--component--test--password--session-key")
+		assert.Equal(t, 32, password.Spec.Length)
+		assert.Equal(t, "generators.external-secrets.io/v1alpha1", password.APIVersion)
+
+		secret := findResourceOrFail[*es.ExternalSecret](t, r, "ExternalSecret", "service--component--test--password--session-key")
+		assert.Equal(t, &es.GeneratorRef{
+			APIVersion: "generators.external-secrets.io/v1alpha1",
+			Kind:       "Password",
+			Name:       "service--component--test--password--session-key",
+		}, secret.Spec.DataFrom[0].SourceRef.GeneratorRef)
+		assert.Equal(t, time.Duration(0), secret.Spec.RefreshInterval.Duration)
+		assert.Equal(t, map[string]string{"SESSION_KEY": "{{ .password }}"}, secret.Spec.Target.Template.Data)
+		assert.Empty(t, secret.Spec.SecretStoreRef.Name)
+
+		ecr := findResourceOrFail[*es.ExternalSecret](t, r, "ExternalSecret", "service--component--test--ecr-token--registry")
+		assert.Equal(t, "ECRAuthorizationToken", ecr.Spec.DataFrom[0].SourceRef.GeneratorRef.Kind)
+		assert.Equal(t, time.Hour, ecr.Spec.RefreshInterval.Duration)
+		assert.Nil(t, ecr.Spec.Target.Template)
+	})
+
+	t.Run("wires the generated secret as envs", func(t *testing.T) {
+		_, envsFrom := getEnvs(values.Containers[0], values.Metadata)
+		require.Len(t, envsFrom, 1)
+		assert.Equal(t, "service--component--test--password--session-key", envsFrom[0].SecretRef.Name)
+	})
+
+	t.Run("fails with more than one generator", func(t *testing.T) {
+		v := DeploymentValues{}
+		copier.CopyWithOption(&v, &values, copier.Option{DeepCopy: true})
+		v.Containers[0].ExternalSecrets[0].Generate.GCRAccessToken = &gen.GCRAccessTokenSpec{ProjectID: "project"}
+		_, create := CreateExternalSecrets(v)
+		_, err := create(v)
+		require.ErrorContains(t, err, "exactly one of")
+	})
+}
@@ -6,10 +6,10 @@ import (
 	"maps"
 	"slices"
 	"sort"
-	"strings"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 func sortedMap[T any](m map[string]T) iter.Seq2[string, T] {
@@ -25,13 +25,8 @@ func sortedMap[T any](m map[string]T) iter.Seq2[string, T] {
 	}
 }
 
-func serviceName(metadata Metadata) string {
-	s := fmt.Sprintf("%s--%s--%s", metadata.Service, metadata.Component, metadata.Environment)
-	return strings.TrimSpace(s)
-}
-
 func commonLabels(metadata Metadata) map[string]string {
-	return map[string]string{
+	labels := map[string]string{
 		"app":                          serviceName(metadata),
 		"namespace":                    metadata.Namespace,
 		"service":                      metadata.Service,
@@ -40,6 +35,12 @@ func commonLabels(metadata Metadata) map[string]string {
 		"yoke-flight-version":          Version,
 		"app.kubernetes.io/managed-by": "yoke",
 	}
+	maps.Copy(labels, recommendedLabels(metadata))
+	// tags that aren't valid label values (too long, digests...) are left out rather than failing the render
+	if metadata.AppVersion != "" && len(validation.IsValidLabelValue(metadata.AppVersion)) == 0 {
+		labels["app.kubernetes.io/version"] = metadata.AppVersion
+	}
+	return labels
 }
 
 func withCommonLabels(labels map[string]string, metadata Metadata) map[string]string {
@@ -49,30 +50,6 @@ func withCommonLabels(labels map[string]string, metadata Metadata) map[string]st
 	return dst
 }
 
-func pvcName(volumeName string, metadata Metadata) string {
-	return fmt.Sprintf("%s--%s", serviceName(metadata), volumeName)
-}
-
-func secretName(secretPath, secretStoreName string, metadata Metadata) string {
-	path := strings.Clone(secretPath)
-	path = strings.ReplaceAll(path, "/", "-")
-
-	name := fmt.Sprintf("%s--%s--%s", serviceName(metadata), secretStoreName, path)
-	targetLength := min(len(name), 253)
-	name = name[:targetLength]
-	name = strings.TrimSuffix(name, "-")
-	return name
-}
-
-func preDeploymentJobName(metadata Metadata) string {
-	// TODO: add something to make it unique?? chart had `Release.Revision`
-	return fmt.Sprintf("%s--pre-deploy", serviceName(metadata))
-}
-
-func cronjobName(cronjob Cronjob) string {
-	return fmt.Sprintf("%s--%s", cronjob.Name, cronjob.Metadata.Environment)
-}
-
 func toUnstructured(objects ...runtime.Object) ([]unstructured.Unstructured, error) {
 	var (
 		ret    []unstructured.Unstructured
@@ -1,8 +1,6 @@
 package resources
 
 import (
-	"fmt"
-
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -11,14 +9,14 @@ import (
 func CreateHttpRoutes(values DeploymentValues) (bool, ResourceCreator) {
 	return len(values.HTTPRoutes) > 0, func(values DeploymentValues) ([]NamedResource, error) {
 		var resources []NamedResource
-		for name, route := range values.HTTPRoutes {
+		for name, route := range sortedMap(values.HTTPRoutes) {
 			httpRoute := gatewayv1.HTTPRoute{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: gatewayv1.SchemeGroupVersion.Identifier(),
 					Kind:       "HTTPRoute",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name:        fmt.Sprintf("%s-%s", serviceName(values.Metadata), name),
+					Name:        httpRouteName(name, values.Metadata),
 					Namespace:   values.Metadata.Namespace,
 					Annotations: route.Annotations,
 					Labels:      withCommonLabels(route.Labels, values.Metadata),
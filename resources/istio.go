@@ -0,0 +1,190 @@
+package resources
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/ProRocketeers/yoke-chart/resources/istio"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateIstio(values DeploymentValues) (bool, ResourceCreator) {
+	create := values.Istio != nil && (values.Istio.VirtualService != nil ||
+		values.Istio.DestinationRule != nil ||
+		values.Istio.PeerAuthentication != nil ||
+		values.Istio.AuthorizationPolicy != nil)
+	return create, func(values DeploymentValues) ([]NamedResource, error) {
+		resources := []NamedResource{}
+		config := values.Istio
+		// the workload's Pods, same labels the Service/PDB select on
+		selector := &istio.WorkloadSelector{
+			MatchLabels: podSelectorLabels(values),
+		}
+
+		if config.VirtualService != nil {
+			vs := istio.VirtualService{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: istio.NetworkingGroupVersion.Identifier(),
+					Kind:       "VirtualService",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        serviceName(values.Metadata),
+					Namespace:   values.Metadata.Namespace,
+					Annotations: config.VirtualService.Annotations,
+					Labels:      withCommonLabels(config.VirtualService.Labels, values.Metadata),
+				},
+				Spec: withVirtualServiceDefaults(istio.VirtualServiceSpec{
+					Hosts:    config.VirtualService.Hosts,
+					Gateways: config.VirtualService.Gateways,
+					HTTP:     config.VirtualService.HTTP,
+				}, values),
+			}
+			u, err := toUnstructured(&vs)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryVirtualService, Object: u[0]})
+		}
+
+		if config.DestinationRule != nil {
+			dr := istio.DestinationRule{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: istio.NetworkingGroupVersion.Identifier(),
+					Kind:       "DestinationRule",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        serviceName(values.Metadata),
+					Namespace:   values.Metadata.Namespace,
+					Annotations: config.DestinationRule.Annotations,
+					Labels:      withCommonLabels(config.DestinationRule.Labels, values.Metadata),
+				},
+				Spec: istio.DestinationRuleSpec{
+					Host:          istioServiceHost(values),
+					TrafficPolicy: config.DestinationRule.TrafficPolicy,
+					Subsets:       config.DestinationRule.Subsets,
+				},
+			}
+			u, err := toUnstructured(&dr)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryDestinationRule, Object: u[0]})
+		}
+
+		if config.PeerAuthentication != nil {
+			pa := istio.PeerAuthentication{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: istio.SecurityGroupVersion.Identifier(),
+					Kind:       "PeerAuthentication",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName(values.Metadata),
+					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
+				},
+				Spec: istio.PeerAuthenticationSpec{
+					Selector:      selector,
+					Mtls:          config.PeerAuthentication.Mtls,
+					PortLevelMtls: config.PeerAuthentication.PortLevelMtls,
+				},
+			}
+			u, err := toUnstructured(&pa)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryPeerAuthentication, Object: u[0]})
+		}
+
+		if config.AuthorizationPolicy != nil {
+			ap := istio.AuthorizationPolicy{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: istio.SecurityGroupVersion.Identifier(),
+					Kind:       "AuthorizationPolicy",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName(values.Metadata),
+					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
+				},
+				Spec: istio.AuthorizationPolicySpec{
+					Selector: selector,
+					Action:   config.AuthorizationPolicy.Action,
+					Rules:    config.AuthorizationPolicy.Rules,
+				},
+			}
+			u, err := toUnstructured(&ap)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryAuthorizationPolicy, Object: u[0]})
+		}
+
+		return resources, nil
+	}
+}
+
+// withVirtualServiceDefaults binds the VirtualService to the chart's Service - `hosts` default to it, and so does
+// every route destination that doesn't name a `host` of its own (routes without any destination get one too)
+func withVirtualServiceDefaults(spec istio.VirtualServiceSpec, values DeploymentValues) istio.VirtualServiceSpec {
+	host := istioServiceHost(values)
+	if len(spec.Hosts) == 0 {
+		spec.Hosts = []string{host}
+	}
+
+	// Istio requires the port to be picked explicitly when the Service exposes more than one
+	var defaultPort *istio.PortSelector
+	if ports := getServicePorts(values); len(ports) > 1 {
+		defaultPort = &istio.PortSelector{Number: uint32(ports[0].Port)}
+	}
+
+	for i := range spec.HTTP {
+		if len(spec.HTTP[i].Route) == 0 {
+			spec.HTTP[i].Route = []istio.HTTPRouteDestination{{}}
+		}
+		for j := range spec.HTTP[i].Route {
+			destination := &spec.HTTP[i].Route[j].Destination
+			if destination.Host == "" {
+				destination.Host = host
+				if destination.Port == nil {
+					destination.Port = defaultPort
+				}
+			}
+		}
+	}
+	return spec
+}
+
+// istioServiceHost returns the FQDN of the chart's Service - Istio only expands host names without dots, so the
+// cluster domain has to be part of it
+func istioServiceHost(values DeploymentValues) string {
+	clusterDomain := "cluster.local"
+	if values.Istio.ClusterDomain != "" {
+		clusterDomain = values.Istio.ClusterDomain
+	}
+	return fmt.Sprintf("%s.%s.svc.%s", serviceName(values.Metadata), values.Metadata.Namespace, clusterDomain)
+}
+
+// istioPodLabels returns the sidecar injection label for the chart's Pod templates, nil if injection isn't configured
+func istioPodLabels(values DeploymentValues) map[string]string {
+	if values.Istio == nil || values.Istio.Injection == nil {
+		return nil
+	}
+	return map[string]string{
+		"sidecar.istio.io/inject": fmt.Sprintf("%t", *values.Istio.Injection.Enabled),
+	}
+}
+
+// istioPodAnnotations returns the extra sidecar annotations for the chart's Pod templates. Job Pods (pre-deployment
+// job, cronjobs) also get the sidecar injected as a native sidecar - Kubernetes then stops it once the job's
+// containers finish, otherwise the still running proxy would keep the Job from ever completing
+func istioPodAnnotations(values DeploymentValues, isJob bool) map[string]string {
+	if values.Istio == nil || values.Istio.Injection == nil || !*values.Istio.Injection.Enabled {
+		return nil
+	}
+	annotations := map[string]string{}
+	if isJob {
+		annotations["sidecar.istio.io/nativeSidecar"] = "true"
+	}
+	maps.Copy(annotations, values.Istio.Injection.Annotations)
+	return annotations
+}
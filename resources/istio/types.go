@@ -0,0 +1,280 @@
+package istio
+
+// -----------------------
+// trimmed down copy of the Istio CRD types from https://github.com/istio/api (networking/v1 and security/v1)
+// same reasoning as the `postgresql`/`cilium` packages - `istio.io/client-go` is generated from protobuf and drags
+// the whole gogo/protobuf toolchain along, which doesn't play well with WASM
+// only the fields the chart exposes are kept, JSON field names are unchanged (durations are plain strings, e.g. `5s`)
+// -----------------------
+
+import (
+	"encoding/json"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	NetworkingGroupVersion = schema.GroupVersion{Group: "networking.istio.io", Version: "v1"}
+	SecurityGroupVersion   = schema.GroupVersion{Group: "security.istio.io", Version: "v1"}
+)
+
+// ------------ networking.istio.io/v1
+
+type VirtualService struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec VirtualServiceSpec `json:"spec"`
+}
+
+type VirtualServiceSpec struct {
+	Hosts    []string    `json:"hosts,omitempty"`
+	Gateways []string    `json:"gateways,omitempty"`
+	HTTP     []HTTPRoute `json:"http,omitempty"`
+}
+
+type HTTPRoute struct {
+	Name    string                 `json:"name,omitempty"`
+	Match   []HTTPMatchRequest     `json:"match,omitempty"`
+	Route   []HTTPRouteDestination `json:"route,omitempty"`
+	Timeout string                 `json:"timeout,omitempty"`
+	Retries *HTTPRetry             `json:"retries,omitempty"`
+	Fault   *HTTPFaultInjection    `json:"fault,omitempty"`
+	Headers *Headers               `json:"headers,omitempty"`
+}
+
+type HTTPMatchRequest struct {
+	Name          string                 `json:"name,omitempty"`
+	URI           *StringMatch           `json:"uri,omitempty"`
+	Method        *StringMatch           `json:"method,omitempty"`
+	Authority     *StringMatch           `json:"authority,omitempty"`
+	Headers       map[string]StringMatch `json:"headers,omitempty"`
+	QueryParams   map[string]StringMatch `json:"queryParams,omitempty"`
+	IgnoreURICase bool                   `json:"ignoreUriCase,omitempty"`
+}
+
+// StringMatch - exactly one of the fields should be set
+type StringMatch struct {
+	Exact  string `json:"exact,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	Regex  string `json:"regex,omitempty"`
+}
+
+type HTTPRouteDestination struct {
+	Destination Destination `json:"destination"`
+	Weight      int32       `json:"weight,omitempty"`
+	Headers     *Headers    `json:"headers,omitempty"`
+}
+
+type Destination struct {
+	Host   string        `json:"host"`
+	Subset string        `json:"subset,omitempty"`
+	Port   *PortSelector `json:"port,omitempty"`
+}
+
+type PortSelector struct {
+	Number uint32 `json:"number,omitempty"`
+}
+
+type HTTPRetry struct {
+	Attempts              int32  `json:"attempts"`
+	PerTryTimeout         string `json:"perTryTimeout,omitempty"`
+	RetryOn               string `json:"retryOn,omitempty"`
+	RetryRemoteLocalities *bool  `json:"retryRemoteLocalities,omitempty"`
+}
+
+type HTTPFaultInjection struct {
+	Delay *FaultDelay `json:"delay,omitempty"`
+	Abort *FaultAbort `json:"abort,omitempty"`
+}
+
+type FaultDelay struct {
+	FixedDelay string      `json:"fixedDelay"`
+	Percentage *Percentage `json:"percentage,omitempty"`
+}
+
+type FaultAbort struct {
+	HTTPStatus int32       `json:"httpStatus,omitempty"`
+	GRPCStatus string      `json:"grpcStatus,omitempty"`
+	Percentage *Percentage `json:"percentage,omitempty"`
+}
+
+type Percentage struct {
+	Value float64 `json:"value"`
+}
+
+type Headers struct {
+	Request  *HeaderOperations `json:"request,omitempty"`
+	Response *HeaderOperations `json:"response,omitempty"`
+}
+
+type HeaderOperations struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+type DestinationRule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec DestinationRuleSpec `json:"spec"`
+}
+
+type DestinationRuleSpec struct {
+	Host          string         `json:"host"`
+	TrafficPolicy *TrafficPolicy `json:"trafficPolicy,omitempty"`
+	Subsets       []Subset       `json:"subsets,omitempty"`
+}
+
+type TrafficPolicy struct {
+	LoadBalancer     *LoadBalancerSettings `json:"loadBalancer,omitempty"`
+	ConnectionPool   *ConnectionPool       `json:"connectionPool,omitempty"`
+	OutlierDetection *OutlierDetection     `json:"outlierDetection,omitempty"`
+}
+
+type LoadBalancerSettings struct {
+	// one of `UNSPECIFIED`, `LEAST_REQUEST`, `RANDOM`, `PASSTHROUGH`, `ROUND_ROBIN`, `LEAST_CONN`
+	Simple string `json:"simple,omitempty"`
+}
+
+type ConnectionPool struct {
+	TCP  *TCPSettings  `json:"tcp,omitempty"`
+	HTTP *HTTPSettings `json:"http,omitempty"`
+}
+
+type TCPSettings struct {
+	MaxConnections int32  `json:"maxConnections,omitempty"`
+	ConnectTimeout string `json:"connectTimeout,omitempty"`
+}
+
+type HTTPSettings struct {
+	HTTP1MaxPendingRequests  int32  `json:"http1MaxPendingRequests,omitempty"`
+	HTTP2MaxRequests         int32  `json:"http2MaxRequests,omitempty"`
+	MaxRequestsPerConnection int32  `json:"maxRequestsPerConnection,omitempty"`
+	MaxRetries               int32  `json:"maxRetries,omitempty"`
+	IdleTimeout              string `json:"idleTimeout,omitempty"`
+}
+
+type OutlierDetection struct {
+	Consecutive5xxErrors     *int32 `json:"consecutive5xxErrors,omitempty"`
+	ConsecutiveGatewayErrors *int32 `json:"consecutiveGatewayErrors,omitempty"`
+	Interval                 string `json:"interval,omitempty"`
+	BaseEjectionTime         string `json:"baseEjectionTime,omitempty"`
+	MaxEjectionPercent       int32  `json:"maxEjectionPercent,omitempty"`
+	MinHealthPercent         int32  `json:"minHealthPercent,omitempty"`
+}
+
+type Subset struct {
+	Name          string            `json:"name" validate:"required"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	TrafficPolicy *TrafficPolicy    `json:"trafficPolicy,omitempty"`
+}
+
+// ------------ security.istio.io/v1
+
+type WorkloadSelector struct {
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+}
+
+type PeerAuthentication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec PeerAuthenticationSpec `json:"spec"`
+}
+
+type PeerAuthenticationSpec struct {
+	Selector      *WorkloadSelector    `json:"selector,omitempty"`
+	Mtls          *MutualTLS           `json:"mtls,omitempty"`
+	PortLevelMtls map[string]MutualTLS `json:"portLevelMtls,omitempty" validate:"dive"`
+}
+
+type MutualTLS struct {
+	// one of `UNSET`, `DISABLE`, `PERMISSIVE`, `STRICT`
+	Mode string `json:"mode,omitempty" validate:"omitempty,oneof=UNSET DISABLE PERMISSIVE STRICT"`
+}
+
+type AuthorizationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec AuthorizationPolicySpec `json:"spec"`
+}
+
+type AuthorizationPolicySpec struct {
+	Selector *WorkloadSelector `json:"selector,omitempty"`
+	Rules    []Rule            `json:"rules,omitempty"`
+	// one of `ALLOW`, `DENY`, `AUDIT`, `CUSTOM`
+	Action string `json:"action,omitempty"`
+}
+
+type Rule struct {
+	From []RuleFrom  `json:"from,omitempty"`
+	To   []RuleTo    `json:"to,omitempty"`
+	When []Condition `json:"when,omitempty" validate:"dive"`
+}
+
+type RuleFrom struct {
+	Source Source `json:"source"`
+}
+
+type Source struct {
+	Principals           []string `json:"principals,omitempty"`
+	NotPrincipals        []string `json:"notPrincipals,omitempty"`
+	RequestPrincipals    []string `json:"requestPrincipals,omitempty"`
+	NotRequestPrincipals []string `json:"notRequestPrincipals,omitempty"`
+	Namespaces           []string `json:"namespaces,omitempty"`
+	NotNamespaces        []string `json:"notNamespaces,omitempty"`
+	IPBlocks             []string `json:"ipBlocks,omitempty"`
+	NotIPBlocks          []string `json:"notIpBlocks,omitempty"`
+}
+
+type RuleTo struct {
+	Operation Operation `json:"operation"`
+}
+
+type Operation struct {
+	Hosts      []string `json:"hosts,omitempty"`
+	NotHosts   []string `json:"notHosts,omitempty"`
+	Ports      []string `json:"ports,omitempty"`
+	NotPorts   []string `json:"notPorts,omitempty"`
+	Methods    []string `json:"methods,omitempty"`
+	NotMethods []string `json:"notMethods,omitempty"`
+	Paths      []string `json:"paths,omitempty"`
+	NotPaths   []string `json:"notPaths,omitempty"`
+}
+
+type Condition struct {
+	Key       string   `json:"key" validate:"required"`
+	Values    []string `json:"values,omitempty"`
+	NotValues []string `json:"notValues,omitempty"`
+}
+
+// the `DeepCopyObject` methods are only here so the types satisfy `runtime.Object`, which is what
+// `toUnstructured` expects - objects are copied through a JSON round trip instead of maintaining a
+// generated deep copy for every nested type (all fields are plain JSON-serializable data)
+
+func (in *VirtualService) DeepCopyObject() runtime.Object { return deepCopyJSON(in, &VirtualService{}) }
+func (in *DestinationRule) DeepCopyObject() runtime.Object {
+	return deepCopyJSON(in, &DestinationRule{})
+}
+func (in *PeerAuthentication) DeepCopyObject() runtime.Object {
+	return deepCopyJSON(in, &PeerAuthentication{})
+}
+func (in *AuthorizationPolicy) DeepCopyObject() runtime.Object {
+	return deepCopyJSON(in, &AuthorizationPolicy{})
+}
+
+func deepCopyJSON[T runtime.Object](in, out T) runtime.Object {
+	bytes, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal(bytes, out); err != nil {
+		panic(err)
+	}
+	return out
+}
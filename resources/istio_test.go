@@ -0,0 +1,266 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources/istio"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestIstio(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*DeploymentValues)
+		Asserts         func(*testing.T, []NamedResource)
+	}
+
+	cases := map[string]CaseConfig{
+		"binds the virtual service to the chart's Service": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Istio = &schema.Istio{
+					VirtualService: &schema.IstioVirtualService{
+						HTTP: []istio.HTTPRoute{
+							{
+								Name: "canary",
+								Match: []istio.HTTPMatchRequest{
+									{Headers: map[string]istio.StringMatch{"x-canary": {Exact: "true"}}},
+								},
+								Route: []istio.HTTPRouteDestination{
+									{Destination: istio.Destination{Subset: "canary"}},
+								},
+							},
+							{
+								Name:    "default",
+								Timeout: "5s",
+								Retries: &istio.HTTPRetry{Attempts: 3, PerTryTimeout: "2s", RetryOn: "5xx"},
+								Fault: &istio.HTTPFaultInjection{
+									Abort: &istio.FaultAbort{HTTPStatus: 503, Percentage: &istio.Percentage{Value: 1}},
+								},
+							},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				vs := findResourceOrFail[*istio.VirtualService](t, r, "VirtualService", "service--component--test")
+
+				assert.Equal(t, "networking.istio.io/v1", r[0].Object.GetAPIVersion())
+				assert.Equal(t, []string{"service--component--test.ns.svc.cluster.local"}, vs.Spec.Hosts)
+
+				require.Len(t, vs.Spec.HTTP, 2)
+				assert.Equal(t, "service--component--test.ns.svc.cluster.local", vs.Spec.HTTP[0].Route[0].Destination.Host)
+				assert.Equal(t, "canary", vs.Spec.HTTP[0].Route[0].Destination.Subset)
+				assert.Equal(t, istio.StringMatch{Exact: "true"}, vs.Spec.HTTP[0].Match[0].Headers["x-canary"])
+
+				// route without any destination gets the chart's Service
+				require.Len(t, vs.Spec.HTTP[1].Route, 1)
+				assert.Equal(t, "service--component--test.ns.svc.cluster.local", vs.Spec.HTTP[1].Route[0].Destination.Host)
+				assert.Nil(t, vs.Spec.HTTP[1].Route[0].Destination.Port)
+				assert.Equal(t, "5s", vs.Spec.HTTP[1].Timeout)
+				assert.Equal(t, int32(3), vs.Spec.HTTP[1].Retries.Attempts)
+				assert.Equal(t, int32(503), vs.Spec.HTTP[1].Fault.Abort.HTTPStatus)
+			},
+		},
+		"picks the main port for destinations if the Service has more than one port": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Containers[0].Ports = []schema.Port{{Port: 8080}, {Port: 9090}}
+				dv.Istio = &schema.Istio{
+					VirtualService: &schema.IstioVirtualService{
+						HTTP: []istio.HTTPRoute{{Name: "default"}},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				vs := findResourceOrFail[*istio.VirtualService](t, r, "VirtualService", "service--component--test")
+				assert.Equal(t, &istio.PortSelector{Number: 8080}, vs.Spec.HTTP[0].Route[0].Destination.Port)
+			},
+		},
+		"keeps explicit destination hosts untouched": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Istio = &schema.Istio{
+					VirtualService: &schema.IstioVirtualService{
+						Hosts: []string{"api.example.com"},
+						HTTP: []istio.HTTPRoute{
+							{Route: []istio.HTTPRouteDestination{{Destination: istio.Destination{Host: "legacy"}}}},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				vs := findResourceOrFail[*istio.VirtualService](t, r, "VirtualService", "service--component--test")
+				assert.Equal(t, []string{"api.example.com"}, vs.Spec.Hosts)
+				assert.Equal(t, "legacy", vs.Spec.HTTP[0].Route[0].Destination.Host)
+			},
+		},
+		"renders a destination rule with subsets": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Istio = &schema.Istio{
+					DestinationRule: &schema.IstioDestinationRule{
+						TrafficPolicy: &istio.TrafficPolicy{
+							ConnectionPool: &istio.ConnectionPool{TCP: &istio.TCPSettings{MaxConnections: 100}},
+							OutlierDetection: &istio.OutlierDetection{
+								Consecutive5xxErrors: ptr.To(int32(5)),
+								Interval:             "30s",
+							},
+						},
+						Subsets: []istio.Subset{
+							{Name: "canary", Labels: map[string]string{"version": "canary"}},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				dr := findResourceOrFail[*istio.DestinationRule](t, r, "DestinationRule", "service--component--test")
+				assert.Equal(t, "service--component--test.ns.svc.cluster.local", dr.Spec.Host)
+				assert.Equal(t, int32(100), dr.Spec.TrafficPolicy.ConnectionPool.TCP.MaxConnections)
+				assert.Equal(t, ptr.To(int32(5)), dr.Spec.TrafficPolicy.OutlierDetection.Consecutive5xxErrors)
+				assert.Equal(t, map[string]string{"version": "canary"}, dr.Spec.Subsets[0].Labels)
+			},
+		},
+		"builds the Service host with the cluster domain": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Istio = &schema.Istio{
+					ClusterDomain:   "corp.internal",
+					VirtualService:  &schema.IstioVirtualService{HTTP: []istio.HTTPRoute{{Name: "default"}}},
+					DestinationRule: &schema.IstioDestinationRule{},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				vs := findResourceOrFail[*istio.VirtualService](t, r, "VirtualService", "service--component--test")
+				assert.Equal(t, []string{"service--component--test.ns.svc.corp.internal"}, vs.Spec.Hosts)
+				assert.Equal(t, "service--component--test.ns.svc.corp.internal", vs.Spec.HTTP[0].Route[0].Destination.Host)
+
+				dr := findResourceOrFail[*istio.DestinationRule](t, r, "DestinationRule", "service--component--test")
+				assert.Equal(t, "service--component--test.ns.svc.corp.internal", dr.Spec.Host)
+			},
+		},
+		"scopes peer authentication and authorization policy to the workload": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Istio = &schema.Istio{
+					PeerAuthentication: &schema.IstioPeerAuthentication{
+						Mtls: &istio.MutualTLS{Mode: "STRICT"},
+					},
+					AuthorizationPolicy: &schema.IstioAuthorizationPolicy{
+						Action: "ALLOW",
+						Rules: []istio.Rule{
+							{From: []istio.RuleFrom{{Source: istio.Source{Namespaces: []string{"frontend"}}}}},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				pa := findResourceOrFail[*istio.PeerAuthentication](t, r, "PeerAuthentication", "service--component--test")
+				assert.Equal(t, "security.istio.io/v1", r[0].Object.GetAPIVersion())
+				assert.Equal(t, map[string]string{"app": "service--component--test"}, pa.Spec.Selector.MatchLabels)
+				assert.Equal(t, "STRICT", pa.Spec.Mtls.Mode)
+
+				ap := findResourceOrFail[*istio.AuthorizationPolicy](t, r, "AuthorizationPolicy", "service--component--test")
+				assert.Equal(t, map[string]string{"app": "service--component--test"}, ap.Spec.Selector.MatchLabels)
+				assert.Equal(t, "ALLOW", ap.Spec.Action)
+				assert.Equal(t, []string{"frontend"}, ap.Spec.Rules[0].From[0].Source.Namespaces)
+			},
+		},
+	}
+
+	base := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Containers: []Container{
+			{
+				Name: "main",
+				Image: Image{
+					Repository: "image_repository",
+					Tag:        ptr.To("image_tag"),
+				},
+				Ports: []schema.Port{{Port: 8080}},
+			},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := DeploymentValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+
+			config.ValuesTransform(&values)
+
+			shouldCreate, create := CreateIstio(values)
+			require.True(t, shouldCreate)
+
+			resources, err := create(values)
+			require.NoError(t, err)
+
+			config.Asserts(t, resources)
+		})
+	}
+
+	t.Run("injection alone does not render any Istio objects", func(t *testing.T) {
+		values := DeploymentValues{}
+		copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+		values.Istio = &schema.Istio{Injection: &schema.IstioInjection{Enabled: ptr.To(true)}}
+
+		shouldCreate, _ := CreateIstio(values)
+		assert.False(t, shouldCreate)
+	})
+
+	t.Run("sets sidecar injection on the workload and job Pod templates", func(t *testing.T) {
+		values := DeploymentValues{}
+		copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+		values.Istio = &schema.Istio{
+			Injection: &schema.IstioInjection{
+				Enabled:     ptr.To(true),
+				Annotations: map[string]string{"sidecar.istio.io/proxyCPU": "100m"},
+			},
+		}
+		values.PodLabels = map[string]string{"custom": "label"}
+		jobContainer := Container{Name: "main", Image: Image{Repository: "job", Tag: ptr.To("tag")}}
+		values.PreDeploymentJob = &PreDeploymentJob{Metadata: values.Metadata, Container: jobContainer}
+		values.Cronjobs = []Cronjob{{Metadata: values.Metadata, Name: "cron", Schedule: "* * * * *", Container: jobContainer}}
+
+		_, createDeployment := CreateDeployment(values)
+		r, err := createDeployment(values)
+		require.NoError(t, err)
+		deployment := fromUnstructuredOrPanic[*appsv1.Deployment](r[0])
+		assert.Subset(t, deployment.Spec.Template.Labels, map[string]string{"sidecar.istio.io/inject": "true", "custom": "label"})
+		assert.Equal(t, "100m", deployment.Spec.Template.Annotations["sidecar.istio.io/proxyCPU"])
+		// the workload's sidecar is a regular long-running one
+		assert.NotContains(t, deployment.Spec.Template.Annotations, "sidecar.istio.io/nativeSidecar")
+
+		_, createJob := CreatePreDeploymentJob(values)
+		r, err = createJob(values)
+		require.NoError(t, err)
+		job := fromUnstructuredOrPanic[*batchv1.Job](r[0])
+		assert.Equal(t, "true", job.Spec.Template.Labels["sidecar.istio.io/inject"])
+		assert.Equal(t, "true", job.Spec.Template.Annotations["sidecar.istio.io/nativeSidecar"])
+		assert.Equal(t, "100m", job.Spec.Template.Annotations["sidecar.istio.io/proxyCPU"])
+
+		_, createCronjobs := CreateCronjobs(values)
+		r, err = createCronjobs(values)
+		require.NoError(t, err)
+		cronjob := fromUnstructuredOrPanic[*batchv1.CronJob](r[0])
+		podTemplate := cronjob.Spec.JobTemplate.Spec.Template
+		assert.Equal(t, "true", podTemplate.Labels["sidecar.istio.io/inject"])
+		assert.Equal(t, "true", podTemplate.Annotations["sidecar.istio.io/nativeSidecar"])
+	})
+
+	t.Run("explicitly disabled injection opts the Pods out", func(t *testing.T) {
+		values := DeploymentValues{}
+		copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+		values.Istio = &schema.Istio{Injection: &schema.IstioInjection{Enabled: ptr.To(false)}}
+
+		_, createDeployment := CreateDeployment(values)
+		r, err := createDeployment(values)
+		require.NoError(t, err)
+		deployment := fromUnstructuredOrPanic[*appsv1.Deployment](r[0])
+		assert.Equal(t, "false", deployment.Spec.Template.Labels["sidecar.istio.io/inject"])
+	})
+}
@@ -0,0 +1,96 @@
+package resources
+
+import (
+	"fmt"
+	"maps"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// Services, PDB, network policies, Istio and the PodMonitor select the Pods by the `app` label
+	SelectorLabelsApp = "app"
+	// ... or by `app.kubernetes.io/name` and `app.kubernetes.io/instance`
+	SelectorLabelsRecommended = "recommended"
+)
+
+// see https://kubernetes.io/docs/concepts/overview/working-with-objects/common-labels/
+func recommendedLabels(metadata Metadata) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      appName(metadata),
+		"app.kubernetes.io/instance":  serviceName(metadata),
+		"app.kubernetes.io/component": metadata.Component,
+		"app.kubernetes.io/part-of":   metadata.Service,
+	}
+}
+
+func appName(metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", metadata.Service, metadata.Component), validation.LabelValueMaxLength)
+}
+
+// workloadSelectorLabels select the Pods of the Deployment/StatefulSet itself - the selector is immutable, so it
+// stays on the `app` label regardless of `selectorLabels`
+func workloadSelectorLabels(metadata Metadata) map[string]string {
+	return map[string]string{
+		"app": serviceName(metadata),
+	}
+}
+
+// podSelectorLabels select the main workload's Pods everywhere else. The Pods always carry both label sets, so switching
+// `selectorLabels` never leaves a Pod unselected
+func podSelectorLabels(values DeploymentValues) map[string]string {
+	if values.SelectorLabels == SelectorLabelsRecommended {
+		return map[string]string{
+			"app.kubernetes.io/name":     appName(values.Metadata),
+			"app.kubernetes.io/instance": serviceName(values.Metadata),
+		}
+	}
+	return workloadSelectorLabels(values.Metadata)
+}
+
+// ApplyGlobalMetadata adds `globalLabels` and `globalAnnotations` to every generated object and to the Job/Pod templates of
+// the workloads, the labels and annotations set on the object itself win
+func ApplyGlobalMetadata(values DeploymentValues, resources []NamedResource) error {
+	if len(values.GlobalLabels) == 0 && len(values.GlobalAnnotations) == 0 {
+		return nil
+	}
+	// templates have their own `metadata`, same as the objects
+	templatePaths := map[string][][]string{
+		"Deployment":  {{"spec", "template"}},
+		"StatefulSet": {{"spec", "template"}},
+		"Job":         {{"spec", "template"}},
+		"CronJob":     {{"spec", "jobTemplate"}, {"spec", "jobTemplate", "spec", "template"}},
+	}
+	for i := range resources {
+		object := &resources[i].Object
+		object.SetLabels(withDefaults(object.GetLabels(), values.GlobalLabels))
+		object.SetAnnotations(withDefaults(object.GetAnnotations(), values.GlobalAnnotations))
+
+		for _, path := range templatePaths[object.GetKind()] {
+			spec, found, err := unstructured.NestedMap(object.Object, path...)
+			if err != nil {
+				return fmt.Errorf("%s '%s': %v", object.GetKind(), object.GetName(), err)
+			}
+			if !found {
+				continue
+			}
+			template := unstructured.Unstructured{Object: spec}
+			template.SetLabels(withDefaults(template.GetLabels(), values.GlobalLabels))
+			template.SetAnnotations(withDefaults(template.GetAnnotations(), values.GlobalAnnotations))
+			if err := unstructured.SetNestedMap(object.Object, template.Object, path...); err != nil {
+				return fmt.Errorf("%s '%s': %v", object.GetKind(), object.GetName(), err)
+			}
+		}
+	}
+	return nil
+}
+
+func withDefaults(values, defaults map[string]string) map[string]string {
+	if len(defaults) == 0 {
+		return values
+	}
+	dst := maps.Clone(defaults)
+	maps.Copy(dst, values)
+	return dst
+}
@@ -0,0 +1,186 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestLabels(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, DeploymentValues, error)
+	}
+
+	render := func(t *testing.T, dv DeploymentValues, creators ...func(DeploymentValues) (bool, ResourceCreator)) []NamedResource {
+		t.Helper()
+		resources := []NamedResource{}
+		for _, creator := range creators {
+			shouldCreate, createFn := creator(dv)
+			require.True(t, shouldCreate)
+			r, err := createFn(dv)
+			require.NoError(t, err)
+			resources = append(resources, r...)
+		}
+		return resources
+	}
+
+	cases := map[string]CaseConfig{
+		"adds the recommended labels": {
+			ValuesTransform: func(iv *schema.InputValues) {},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				r := render(t, dv, CreateMainWorkload)
+
+				deployment := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test")
+				for _, labels := range []map[string]string{deployment.Labels, deployment.Spec.Template.Labels} {
+					assert.Equal(t, "service--component--test", labels["app"])
+					assert.Equal(t, "service-component", labels["app.kubernetes.io/name"])
+					assert.Equal(t, "service--component--test", labels["app.kubernetes.io/instance"])
+					assert.Equal(t, "component", labels["app.kubernetes.io/component"])
+					assert.Equal(t, "service", labels["app.kubernetes.io/part-of"])
+					assert.Equal(t, "1.2.3", labels["app.kubernetes.io/version"])
+				}
+			},
+		},
+		"skips version label for tags that aren't valid label values": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Image.Tag = ptr.To("1.2.3@sha256:0123456789abcdef")
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				assert.NotContains(t, commonLabels(dv.Metadata), "app.kubernetes.io/version")
+			},
+		},
+		"selects by app label by default": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.PodDisruptionBudget = &policyv1.PodDisruptionBudgetSpec{}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				r := render(t, dv, CreateMainWorkload, CreateService, CreatePDB)
+
+				selector := map[string]string{"app": "service--component--test"}
+				deployment := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test")
+				assert.Equal(t, selector, deployment.Spec.Selector.MatchLabels)
+				service := findResourceOrFail[*corev1.Service](t, r, "Service", "service--component--test")
+				assert.Equal(t, selector, service.Spec.Selector)
+				pdb := findResourceOrFail[*policyv1.PodDisruptionBudget](t, r, "PodDisruptionBudget", "service--component--test")
+				assert.Equal(t, selector, pdb.Spec.Selector.MatchLabels)
+			},
+		},
+		"selects by recommended labels, keeps workload selector": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.SelectorLabels = ptr.To(SelectorLabelsRecommended)
+				iv.PodDisruptionBudget = &policyv1.PodDisruptionBudgetSpec{}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				r := render(t, dv, CreateMainWorkload, CreateService, CreatePDB)
+
+				deployment := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test")
+				assert.Equal(t, map[string]string{"app": "service--component--test"}, deployment.Spec.Selector.MatchLabels)
+
+				selector := map[string]string{
+					"app.kubernetes.io/name":     "service-component",
+					"app.kubernetes.io/instance": "service--component--test",
+				}
+				service := findResourceOrFail[*corev1.Service](t, r, "Service", "service--component--test")
+				assert.Equal(t, selector, service.Spec.Selector)
+				pdb := findResourceOrFail[*policyv1.PodDisruptionBudget](t, r, "PodDisruptionBudget", "service--component--test")
+				assert.Equal(t, selector, pdb.Spec.Selector.MatchLabels)
+				// the Pods match both selectors
+				assert.Subset(t, deployment.Spec.Template.Labels, selector)
+			},
+		},
+		"labels RBAC objects": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ServiceAccount = &schema.ServiceAccount{
+					AdditionalRole:        &schema.ServiceAccountRole{Rules: []rbacv1.PolicyRule{}},
+					AdditionalClusterRole: &schema.ServiceAccountRole{Rules: []rbacv1.PolicyRule{}},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				r := render(t, dv, CreateRBAC)
+				require.Len(t, r, 4)
+				for _, resource := range r {
+					assert.Equal(t, commonLabels(dv.Metadata), resource.Object.GetLabels(), resource.Object.GetKind())
+				}
+			},
+		},
+		"applies global labels and annotations": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.GlobalLabels = map[string]string{"team": "payments", "environment": "global"}
+				iv.GlobalAnnotations = map[string]string{"owner": "payments@example.com"}
+				iv.PodAnnotations = map[string]string{"owner": "pod-owner"}
+				iv.Cronjobs = []schema.Cronjob{{
+					Name:     "job",
+					Schedule: "* * * * *",
+					Container: schema.Container{
+						Image: schema.Image{Repository: "job", Tag: ptr.To("1")},
+					},
+				}}
+				iv.ConfigMaps = map[string]map[string]string{"config": {"key": "value"}}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				r := render(t, dv, CreateMainWorkload, CreateCronjobs, CreateConfigMaps)
+				require.NoError(t, ApplyGlobalMetadata(dv, r))
+
+				configMap := findResourceOrFail[*corev1.ConfigMap](t, r, "ConfigMap", "service--component--test-config")
+				assert.Equal(t, "payments", configMap.Labels["team"])
+				// the object's own labels win
+				assert.Equal(t, "test", configMap.Labels["environment"])
+				assert.Equal(t, map[string]string{"owner": "payments@example.com"}, configMap.Annotations)
+
+				deployment := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test")
+				assert.Equal(t, "payments", deployment.Spec.Template.Labels["team"])
+				assert.Equal(t, "pod-owner", deployment.Spec.Template.Annotations["owner"])
+				// not part of the selector
+				assert.NotContains(t, deployment.Spec.Selector.MatchLabels, "team")
+
+				cronjob := findResourceOrFail[*batchv1.CronJob](t, r, "CronJob", "job--test")
+				assert.Equal(t, "payments", cronjob.Labels["team"])
+				assert.Equal(t, "payments", cronjob.Spec.JobTemplate.Labels["team"])
+				assert.Equal(t, "payments", cronjob.Spec.JobTemplate.Spec.Template.Labels["team"])
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("1.2.3"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			config.Asserts(t, deploymentValues, err)
+		})
+	}
+}
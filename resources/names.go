@@ -0,0 +1,176 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// all names of the generated objects are built here, capped to what their kind allows
+// see https://kubernetes.io/docs/concepts/overview/working-with-objects/names/
+const (
+	// DNS-1123 subdomain - most objects (ConfigMaps, Secrets, PVCs, NetworkPolicies, CRDs...)
+	maxSubdomainNameLength = validation.DNS1123SubdomainMaxLength
+	// DNS-1123/1035 label - Services, workloads and Jobs (their names end up in hostnames and label values), volumes
+	// and Service port names
+	maxLabelNameLength = validation.DNS1123LabelMaxLength
+	// the CronJob controller appends an 11 character suffix to the Job names, which have to fit into a label
+	maxCronJobNameLength = 52
+)
+
+// truncateName cuts names over the limit, replacing the cut off part with a hash of the whole name - the result is
+// stable across renders, and names sharing a long prefix stay distinct
+func truncateName(name string, maxLength int) string {
+	if len(name) <= maxLength {
+		return name
+	}
+	sum := sha256.Sum256([]byte(name))
+	suffix := hex.EncodeToString(sum[:])[:8]
+	prefix := strings.TrimRight(name[:maxLength-len(suffix)-1], "-.")
+	return fmt.Sprintf("%s-%s", prefix, suffix)
+}
+
+// NameTemplateContext is exposed to `nameTemplate` as the template's "."
+type NameTemplateContext struct {
+	// the yoke release name, empty outside of yoke
+	Release     string
+	Namespace   string
+	Service     string
+	Component   string
+	Environment string
+}
+
+func renderNameTemplate(text string, input schema.InputValues) (string, error) {
+	tmpl, err := template.New("nameTemplate").Funcs(templateFuncs()).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	ctx := NameTemplateContext{
+		Release:     input.Release.Name,
+		Namespace:   input.Metadata.Namespace,
+		Service:     input.Metadata.Service,
+		Component:   input.Metadata.Component,
+		Environment: input.Metadata.Environment,
+	}
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, ctx); err != nil {
+		return "", err
+	}
+	name := strings.TrimSpace(buf.String())
+	if name == "" {
+		return "", fmt.Errorf("template %q renders an empty name", text)
+	}
+	return name, nil
+}
+
+func serviceName(metadata Metadata) string {
+	s := fmt.Sprintf("%s--%s--%s", metadata.Service, metadata.Component, metadata.Environment)
+	if metadata.Name != "" {
+		s = metadata.Name
+	}
+	return truncateName(strings.TrimSpace(s), maxLabelNameLength)
+}
+
+func headlessServiceName(metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-headless", serviceName(metadata)), maxLabelNameLength)
+}
+
+func additionalServiceName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", serviceName(metadata), name), maxLabelNameLength)
+}
+
+func preDeploymentJobName(metadata Metadata) string {
+	// TODO: add something to make it unique?? chart had `Release.Revision`
+	return truncateName(fmt.Sprintf("%s--pre-deploy", serviceName(metadata)), maxLabelNameLength)
+}
+
+func cronjobName(cronjob Cronjob) string {
+	return truncateName(fmt.Sprintf("%s--%s", cronjob.Name, cronjob.Metadata.Environment), maxCronJobNameLength)
+}
+
+func pvcName(volumeName string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s--%s", serviceName(metadata), volumeName), maxSubdomainNameLength)
+}
+
+func configMapName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+// Secrets rendered from `secrets`
+func chartSecretName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+func secretName(secretPath, secretStoreName string, metadata Metadata) string {
+	path := strings.ReplaceAll(secretPath, "/", "-")
+	return truncateName(fmt.Sprintf("%s--%s--%s", serviceName(metadata), secretStoreName, path), maxSubdomainNameLength)
+}
+
+func sealedSecretName(definition schema.SealedSecretDefinition, metadata Metadata) string {
+	return secretName(definition.Name, "sealed", metadata)
+}
+
+func pushSecretName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s--push-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+// shared by NetworkPolicies and CiliumNetworkPolicies, those are different kinds so they can't clash
+func networkPolicyName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+func httpRouteName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+func dashboardName(name string, metadata Metadata) string {
+	return truncateName(fmt.Sprintf("%s--dashboard-%s", serviceName(metadata), name), maxSubdomainNameLength)
+}
+
+// ValidateNames checks the names and labels of the created objects against the rules of their kind, and that no two
+// objects of the same kind ended up with the same name (e.g. two long names truncated to the same prefix, or a
+// `services` entry named `headless`)
+func ValidateNames(resources []NamedResource) error {
+	type objectKey struct{ apiVersion, kind, namespace, name string }
+	seen := map[objectKey]ResourceCategory{}
+
+	for _, r := range resources {
+		kind, name := r.Object.GetKind(), r.Object.GetName()
+
+		var errs []string
+		switch kind {
+		case "Service":
+			errs = validation.IsDNS1035Label(name)
+		case "Deployment", "StatefulSet", "Job":
+			errs = validation.IsDNS1123Label(name)
+		case "CronJob":
+			errs = validation.IsDNS1123Subdomain(name)
+			if len(name) > maxCronJobNameLength {
+				errs = append(errs, validation.MaxLenError(maxCronJobNameLength))
+			}
+		default:
+			errs = validation.IsDNS1123Subdomain(name)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("invalid %s name '%s': %s", kind, name, strings.Join(errs, ", "))
+		}
+
+		for key, value := range sortedMap(r.Object.GetLabels()) {
+			if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+				return fmt.Errorf("invalid value of label '%s' on %s '%s': %s", key, kind, name, strings.Join(errs, ", "))
+			}
+		}
+
+		key := objectKey{r.Object.GetAPIVersion(), kind, r.Object.GetNamespace(), name}
+		if category, ok := seen[key]; ok {
+			return fmt.Errorf("%s name '%s' is used by both %s and %s (names over the length limit are truncated)", kind, name, category, r.Category)
+		}
+		seen[key] = r.Category
+	}
+	return nil
+}
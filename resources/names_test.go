@@ -0,0 +1,153 @@
+package resources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestTruncateName(t *testing.T) {
+	t.Run("keeps names within the limit", func(t *testing.T) {
+		assert.Equal(t, "foo--bar--test", truncateName("foo--bar--test", maxLabelNameLength))
+	})
+
+	t.Run("truncates with a stable hash suffix", func(t *testing.T) {
+		long := strings.Repeat("a", 70)
+		name := truncateName(long, maxLabelNameLength)
+		assert.Len(t, name, maxLabelNameLength)
+		assert.Equal(t, name, truncateName(long, maxLabelNameLength))
+		assert.True(t, strings.HasPrefix(name, strings.Repeat("a", 54)+"-"))
+	})
+
+	t.Run("keeps names with the same prefix distinct", func(t *testing.T) {
+		prefix := strings.Repeat("a", 60)
+		assert.NotEqual(t, truncateName(prefix+"-one", maxLabelNameLength), truncateName(prefix+"-two", maxLabelNameLength))
+	})
+
+	t.Run("doesn't leave a dash before the hash", func(t *testing.T) {
+		name := truncateName(strings.Repeat("a", 53)+"--"+strings.Repeat("b", 20), maxLabelNameLength)
+		assert.NotContains(t, name, "--")
+	})
+}
+
+func TestGeneratedNames(t *testing.T) {
+	metadata := Metadata{
+		Namespace:   "ns",
+		Service:     "a-very-long-service-name-that-goes-on",
+		Component:   "and-a-long-component-name",
+		Environment: "production",
+	}
+
+	assert.Len(t, serviceName(metadata), maxLabelNameLength)
+	assert.Len(t, headlessServiceName(metadata), maxLabelNameLength)
+	assert.Len(t, preDeploymentJobName(metadata), maxLabelNameLength)
+	assert.NotEqual(t, serviceName(metadata), headlessServiceName(metadata))
+
+	cronjob := Cronjob{Name: "a-very-long-cronjob-name-for-nightly-reports", Metadata: metadata}
+	assert.Len(t, cronjobName(cronjob), maxCronJobNameLength)
+}
+
+func TestNameTemplate(t *testing.T) {
+	input := func(nameTemplate string) schema.InputValues {
+		return schema.InputValues{
+			Metadata: schema.Metadata{
+				Namespace:   "ns",
+				Service:     "service",
+				Component:   "component",
+				Environment: "test",
+			},
+			Release:      schema.Release{Name: "payments", Namespace: "ns"},
+			NameTemplate: ptr.To(nameTemplate),
+			Container: schema.Container{
+				Image: schema.Image{Repository: "image_repository", Tag: ptr.To("image_tag")},
+				Ports: []schema.Port{{Port: 8080}},
+			},
+			PreDeploymentJob: &schema.PreDeploymentJob{
+				Container: schema.Container{Image: schema.Image{Repository: "job", Tag: ptr.To("1")}},
+			},
+		}
+	}
+
+	t.Run("renders the service name", func(t *testing.T) {
+		dv, err := PrepareDeploymentValues(input("{{ .Release }}-{{ .Component }}"))
+		require.NoError(t, err)
+		assert.Equal(t, "payments-component", serviceName(dv.Metadata))
+		assert.Equal(t, "payments-component-config", configMapName("config", dv.Metadata))
+		assert.Equal(t, "payments-component--pre-deploy", preDeploymentJobName(dv.PreDeploymentJob.Metadata))
+	})
+
+	t.Run("fails on unknown fields", func(t *testing.T) {
+		_, err := PrepareDeploymentValues(input("{{ .Name }}"))
+		assert.ErrorContains(t, err, "error while rendering nameTemplate")
+	})
+
+	t.Run("fails on empty name", func(t *testing.T) {
+		values := input("{{ .Release }}")
+		values.Release = schema.Release{}
+		_, err := PrepareDeploymentValues(values)
+		assert.ErrorContains(t, err, "renders an empty name")
+	})
+}
+
+func TestValidateNames(t *testing.T) {
+	values := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Containers: []Container{{
+			Name:  "main",
+			Image: Image{Repository: "image", Tag: ptr.To("tag")},
+			Ports: []schema.Port{{Port: 8080}},
+		}},
+		Service: ServiceConfig{Type: corev1.ServiceTypeClusterIP},
+	}
+
+	render := func(t *testing.T, values DeploymentValues) []NamedResource {
+		_, createFn := CreateService(values)
+		r, err := createFn(values)
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("passes the generated objects", func(t *testing.T) {
+		assert.NoError(t, ValidateNames(render(t, values)))
+	})
+
+	t.Run("fails on names clashing between objects", func(t *testing.T) {
+		v := values
+		v.Kind = "StatefulSet"
+		v.Services = map[string]AdditionalService{"headless": {
+			ServiceConfig: ServiceConfig{Type: corev1.ServiceTypeClusterIP},
+			Ports:         []string{"main-port"},
+		}}
+		_, createWorkload := CreateStatefulSet(v)
+		r, err := createWorkload(v)
+		require.NoError(t, err)
+		r = append(r, render(t, v)...)
+
+		assert.ErrorContains(t, ValidateNames(r), "Service name 'service--component--test-headless' is used by both HeadlessService and Service")
+	})
+
+	t.Run("fails on invalid names", func(t *testing.T) {
+		v := values
+		v.Services = map[string]AdditionalService{"Admin_Port": {
+			ServiceConfig: ServiceConfig{Type: corev1.ServiceTypeClusterIP},
+			Ports:         []string{"main-port"},
+		}}
+		assert.ErrorContains(t, ValidateNames(render(t, v)), "invalid Service name 'service--component--test-Admin_Port'")
+	})
+
+	t.Run("fails on invalid label values", func(t *testing.T) {
+		v := values
+		v.Service.Labels = map[string]string{"team": "platform team"}
+		assert.ErrorContains(t, ValidateNames(render(t, v)), "invalid value of label 'team' on Service 'service--component--test'")
+	})
+}
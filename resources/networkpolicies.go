@@ -1,8 +1,6 @@
 package resources
 
 import (
-	"fmt"
-
 	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -10,14 +8,14 @@ import (
 func CreateNetworkPolicies(values DeploymentValues) (bool, ResourceCreator) {
 	return len(values.NetworkPolicies) > 0, func(values DeploymentValues) ([]NamedResource, error) {
 		var resources []NamedResource
-		for name, spec := range values.NetworkPolicies {
+		for name, spec := range sortedMap(values.NetworkPolicies) {
 			np := networkingv1.NetworkPolicy{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: networkingv1.SchemeGroupVersion.Identifier(),
 					Kind:       "NetworkPolicy",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      fmt.Sprintf("%s-%s", serviceName(values.Metadata), name),
+					Name:      networkPolicyName(name, values.Metadata),
 					Namespace: values.Metadata.Namespace,
 					Labels:    commonLabels(values.Metadata),
 				},
@@ -0,0 +1,87 @@
+package resources
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// applyOtelEnvs sets the standard OpenTelemetry SDK envs on every container (including jobs and init containers),
+// envs set explicitly on a container win
+func applyOtelEnvs(values *DeploymentValues) {
+	config := values.Otel
+	serviceName := fmt.Sprintf("%s-%s", values.Metadata.Service, values.Metadata.Component)
+	if config.ServiceName != "" {
+		serviceName = config.ServiceName
+	}
+
+	apply := func(c *Container) {
+		attributes := map[string]string{
+			"service.namespace":           values.Metadata.Service,
+			"service.component":           values.Metadata.Component,
+			"deployment.environment.name": values.Metadata.Environment,
+			"k8s.namespace.name":          values.Metadata.Namespace,
+			"k8s.container.name":          c.Name,
+		}
+		maps.Copy(attributes, config.ResourceAttributes)
+		// values are percent-encoded as the OpenTelemetry spec requires, so a `,` in a value doesn't split the list
+		pairs := []string{}
+		for key, value := range sortedMap(attributes) {
+			pairs = append(pairs, fmt.Sprintf("%s=%s", key, url.PathEscape(value)))
+		}
+
+		envs := map[string]string{
+			"OTEL_SERVICE_NAME":        serviceName,
+			"OTEL_RESOURCE_ATTRIBUTES": strings.Join(pairs, ","),
+		}
+		if config.Endpoint != "" {
+			envs["OTEL_EXPORTER_OTLP_ENDPOINT"] = config.Endpoint
+		}
+		if config.Protocol != "" {
+			envs["OTEL_EXPORTER_OTLP_PROTOCOL"] = config.Protocol
+		}
+		// the map is shared with the input (and with other containers through YAML anchors), copy before changing it
+		c.Envs = maps.Clone(c.Envs)
+		if c.Envs == nil {
+			c.Envs = map[string]string{}
+		}
+		for name, value := range envs {
+			if _, ok := c.Envs[name]; !ok && !hasRawEnv(*c, name) {
+				c.Envs[name] = value
+			}
+		}
+	}
+
+	forEachContainer(values, apply)
+}
+
+func hasRawEnv(c Container, name string) bool {
+	return slices.ContainsFunc(c.EnvsRaw, func(env corev1.EnvVar) bool { return env.Name == name })
+}
+
+// otelPodAnnotations returns the OpenTelemetry Operator auto-instrumentation annotations for the main workload's Pod
+// template, nil if no container is instrumented. Containers are listed per language, so a single Pod can mix them
+func otelPodAnnotations(values DeploymentValues) map[string]string {
+	if values.Otel == nil || len(values.Otel.Instrumentation) == 0 {
+		return nil
+	}
+	ref := "true"
+	if values.Otel.InstrumentationRef != "" {
+		ref = values.Otel.InstrumentationRef
+	}
+
+	containersByLanguage := map[string][]string{}
+	for container, language := range sortedMap(values.Otel.Instrumentation) {
+		containersByLanguage[language] = append(containersByLanguage[language], container)
+	}
+	annotations := map[string]string{}
+	for language, containers := range containersByLanguage {
+		annotations[fmt.Sprintf("instrumentation.opentelemetry.io/inject-%s", language)] = ref
+		annotations[fmt.Sprintf("instrumentation.opentelemetry.io/%s-container-names", language)] = strings.Join(containers, ",")
+	}
+	return annotations
+}
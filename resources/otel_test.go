@@ -0,0 +1,193 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestOtelEnvs(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, DeploymentValues, error)
+	}
+
+	shared := map[string]string{"LOG_LEVEL": "info"}
+
+	cases := map[string]CaseConfig{
+		"sets the envs on every container": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{Endpoint: "http://collector.monitoring:4317", Protocol: "grpc"}
+				iv.Sidecars = map[string]schema.Container{
+					"proxy": {Image: schema.Image{Repository: "proxy", Tag: ptr.To("1")}},
+				}
+				iv.Cronjobs = []schema.Cronjob{
+					{
+						Name:      "cleanup",
+						Schedule:  "* * * * *",
+						Container: schema.Container{Image: schema.Image{Repository: "cleanup", Tag: ptr.To("1")}},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				main := dv.Containers[0].Envs
+				assert.Equal(t, "service-component", main["OTEL_SERVICE_NAME"])
+				assert.Equal(t, "http://collector.monitoring:4317", main["OTEL_EXPORTER_OTLP_ENDPOINT"])
+				assert.Equal(t, "grpc", main["OTEL_EXPORTER_OTLP_PROTOCOL"])
+				assert.Equal(t,
+					"deployment.environment.name=test,k8s.container.name=main,k8s.namespace.name=ns,service.component=component,service.namespace=service",
+					main["OTEL_RESOURCE_ATTRIBUTES"],
+				)
+
+				assert.Contains(t, dv.Containers[1].Envs["OTEL_RESOURCE_ATTRIBUTES"], "k8s.container.name=proxy")
+				assert.Equal(t, "service-component", dv.Cronjobs[0].Container.Envs["OTEL_SERVICE_NAME"])
+			},
+		},
+		"skips the endpoint when not set": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.NotContains(t, dv.Containers[0].Envs, "OTEL_EXPORTER_OTLP_ENDPOINT")
+				assert.NotContains(t, dv.Containers[0].Envs, "OTEL_EXPORTER_OTLP_PROTOCOL")
+			},
+		},
+		"adds and overrides resource attributes": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{
+					ServiceName:        "checkout",
+					ResourceAttributes: map[string]string{"team": "payments", "service.namespace": "shop"},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "checkout", dv.Containers[0].Envs["OTEL_SERVICE_NAME"])
+				assert.Equal(t,
+					"deployment.environment.name=test,k8s.container.name=main,k8s.namespace.name=ns,service.component=component,service.namespace=shop,team=payments",
+					dv.Containers[0].Envs["OTEL_RESOURCE_ATTRIBUTES"],
+				)
+			},
+		},
+		"percent-encodes resource attribute values": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{
+					ResourceAttributes: map[string]string{"team": "payments, billing", "owner": "50%"},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				attributes := dv.Containers[0].Envs["OTEL_RESOURCE_ATTRIBUTES"]
+				assert.Contains(t, attributes, ",team=payments%2C%20billing")
+				assert.Contains(t, attributes, ",owner=50%25,")
+			},
+		},
+		"doesn't write into envs shared between containers": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{}
+				// what a YAML anchor (`envs: *common`) decodes into
+				iv.Envs = shared
+				iv.Sidecars = map[string]schema.Container{
+					"proxy": {Image: schema.Image{Repository: "proxy", Tag: ptr.To("1")}, Envs: shared},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Contains(t, dv.Containers[0].Envs["OTEL_RESOURCE_ATTRIBUTES"], "k8s.container.name=main")
+				assert.Contains(t, dv.Containers[1].Envs["OTEL_RESOURCE_ATTRIBUTES"], "k8s.container.name=proxy")
+				assert.Equal(t, "info", dv.Containers[1].Envs["LOG_LEVEL"])
+				assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, shared)
+			},
+		},
+		"explicit container envs win": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Otel = &schema.Otel{Endpoint: "http://collector.monitoring:4317"}
+				iv.Envs = map[string]string{"OTEL_SERVICE_NAME": "custom"}
+				iv.EnvsRaw = []corev1.EnvVar{{Name: "OTEL_EXPORTER_OTLP_ENDPOINT", Value: "http://localhost:4317"}}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.Nil(t, err)
+				assert.Equal(t, "custom", dv.Containers[0].Envs["OTEL_SERVICE_NAME"])
+				assert.NotContains(t, dv.Containers[0].Envs, "OTEL_EXPORTER_OTLP_ENDPOINT")
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("image_tag"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			config.Asserts(t, deploymentValues, err)
+		})
+	}
+}
+
+func TestOtelInstrumentation(t *testing.T) {
+	values := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Containers: []Container{
+			{Name: "main", Image: Image{Repository: "image_repository", Tag: ptr.To("image_tag")}, Ports: []schema.Port{{Port: 8080}}},
+			{Name: "worker", Image: Image{Repository: "image_repository", Tag: ptr.To("image_tag")}},
+			{Name: "exporter", Image: Image{Repository: "exporter", Tag: ptr.To("1")}},
+		},
+		Otel: &schema.Otel{
+			Instrumentation:    map[string]string{"main": "java", "worker": "java", "exporter": "python"},
+			InstrumentationRef: "monitoring/default",
+		},
+	}
+
+	t.Run("annotates the Pod template per language", func(t *testing.T) {
+		_, createDeployment := CreateDeployment(values)
+		r, err := createDeployment(values)
+		require.Nil(t, err)
+
+		deployment := findResourceOrFail[*appsv1.Deployment](t, r, "Deployment", "service--component--test")
+		annotations := deployment.Spec.Template.Annotations
+		assert.Equal(t, "monitoring/default", annotations["instrumentation.opentelemetry.io/inject-java"])
+		assert.Equal(t, "main,worker", annotations["instrumentation.opentelemetry.io/java-container-names"])
+		assert.Equal(t, "monitoring/default", annotations["instrumentation.opentelemetry.io/inject-python"])
+		assert.Equal(t, "exporter", annotations["instrumentation.opentelemetry.io/python-container-names"])
+	})
+
+	t.Run("injects the namespace's Instrumentation by default", func(t *testing.T) {
+		v := DeploymentValues{}
+		copier.CopyWithOption(&v, &values, copier.Option{DeepCopy: true})
+		v.Otel.InstrumentationRef = ""
+		assert.Equal(t, "true", otelPodAnnotations(v)["instrumentation.opentelemetry.io/inject-java"])
+	})
+
+	t.Run("no annotations without instrumentation", func(t *testing.T) {
+		assert.Nil(t, otelPodAnnotations(DeploymentValues{Otel: &schema.Otel{}}))
+	})
+}
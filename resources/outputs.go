@@ -4,49 +4,72 @@ package resources
 // that was actually created rather than re-derived from a naming formula, so it can never drift
 // out of sync (e.g. a Role's name being overridden via `AdditionalRole.Name`).
 type Ref struct {
-	Name      string
-	Namespace string
-	Kind      string
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Kind      string `json:"kind"`
 }
 
 // Outputs exposes references to every resource the chart creates, keyed the same way the user
 // referred to it in their input (e.g. the HTTPRoute's map key), for use in extraManifests
-// templating. Singular fields are nil if that resource wasn't created.
+// templating. Singular fields are nil if that resource wasn't created. Also reported as the
+// `status.outputs` of the Airway's custom resource, hence the JSON names.
 type Outputs struct {
-	Workload                *Ref
-	HeadlessService         *Ref
-	Service                 *Ref
-	Ingress                 *Ref
-	ServiceAccount          *Ref
-	PreDeploymentJob        *Ref
-	HPA                     *Ref
-	PDB                     *Ref
-	DB                      *Ref
-	Role                    *Ref
-	RoleBinding             *Ref
-	ClusterRole             *Ref
-	ClusterRoleBinding      *Ref
-	ServiceMonitor          *Ref
-	PreDeploymentPodMonitor *Ref
+	Workload                *Ref `json:"workload,omitempty"`
+	HeadlessService         *Ref `json:"headlessService,omitempty"`
+	Service                 *Ref `json:"service,omitempty"`
+	Ingress                 *Ref `json:"ingress,omitempty"`
+	ServiceAccount          *Ref `json:"serviceAccount,omitempty"`
+	PreDeploymentJob        *Ref `json:"preDeploymentJob,omitempty"`
+	HPA                     *Ref `json:"hpa,omitempty"`
+	PDB                     *Ref `json:"pdb,omitempty"`
+	DB                      *Ref `json:"db,omitempty"`
+	Role                    *Ref `json:"role,omitempty"`
+	RoleBinding             *Ref `json:"roleBinding,omitempty"`
+	ClusterRole             *Ref `json:"clusterRole,omitempty"`
+	ClusterRoleBinding      *Ref `json:"clusterRoleBinding,omitempty"`
+	ServiceMonitor          *Ref `json:"serviceMonitor,omitempty"`
+	PodMonitor              *Ref `json:"podMonitor,omitempty"`
+	PreDeploymentPodMonitor *Ref `json:"preDeploymentPodMonitor,omitempty"`
+	PrometheusRule          *Ref `json:"prometheusRule,omitempty"`
+	VirtualService          *Ref `json:"virtualService,omitempty"`
+	DestinationRule         *Ref `json:"destinationRule,omitempty"`
+	PeerAuthentication      *Ref `json:"peerAuthentication,omitempty"`
+	AuthorizationPolicy     *Ref `json:"authorizationPolicy,omitempty"`
 
-	HTTPRoutes         map[string]Ref
-	NetworkPolicies    map[string]Ref
-	ConfigMaps         map[string]Ref
-	PVCs               map[string]Ref
-	Cronjobs           map[string]Ref
-	CronjobPodMonitors map[string]Ref
-	ExternalSecrets    map[string]Ref
+	Services              map[string]Ref `json:"services,omitempty"`
+	HTTPRoutes            map[string]Ref `json:"httpRoutes,omitempty"`
+	NetworkPolicies       map[string]Ref `json:"networkPolicies,omitempty"`
+	CiliumNetworkPolicies map[string]Ref `json:"ciliumNetworkPolicies,omitempty"`
+	ConfigMaps            map[string]Ref `json:"configMaps,omitempty"`
+	Secrets               map[string]Ref `json:"secrets,omitempty"`
+	Dashboards            map[string]Ref `json:"dashboards,omitempty"`
+	PVCs                  map[string]Ref `json:"pvcs,omitempty"`
+	Cronjobs              map[string]Ref `json:"cronjobs,omitempty"`
+	CronjobPodMonitors    map[string]Ref `json:"cronjobPodMonitors,omitempty"`
+	ExternalSecrets       map[string]Ref `json:"externalSecrets,omitempty"`
+	PushSecrets           map[string]Ref `json:"pushSecrets,omitempty"`
+	SealedSecrets         map[string]Ref `json:"sealedSecrets,omitempty"`
+
+	// resources of the extensions, keyed by their category and key
+	Custom map[string]map[string]Ref `json:"custom,omitempty"`
 }
 
 func BuildOutputs(resources []NamedResource) Outputs {
 	outputs := Outputs{
-		HTTPRoutes:         map[string]Ref{},
-		NetworkPolicies:    map[string]Ref{},
-		ConfigMaps:         map[string]Ref{},
-		PVCs:               map[string]Ref{},
-		Cronjobs:           map[string]Ref{},
-		CronjobPodMonitors: map[string]Ref{},
-		ExternalSecrets:    map[string]Ref{},
+		Services:              map[string]Ref{},
+		HTTPRoutes:            map[string]Ref{},
+		NetworkPolicies:       map[string]Ref{},
+		CiliumNetworkPolicies: map[string]Ref{},
+		ConfigMaps:            map[string]Ref{},
+		Secrets:               map[string]Ref{},
+		Dashboards:            map[string]Ref{},
+		PVCs:                  map[string]Ref{},
+		Cronjobs:              map[string]Ref{},
+		CronjobPodMonitors:    map[string]Ref{},
+		ExternalSecrets:       map[string]Ref{},
+		PushSecrets:           map[string]Ref{},
+		SealedSecrets:         map[string]Ref{},
+		Custom:                map[string]map[string]Ref{},
 	}
 
 	for _, r := range resources {
@@ -62,7 +85,12 @@ func BuildOutputs(resources []NamedResource) Outputs {
 		case CategoryHeadlessService:
 			outputs.HeadlessService = &ref
 		case CategoryService:
-			outputs.Service = &ref
+			// the main Service has no key, additional ones (`services`) are keyed by their name
+			if r.Key == "" {
+				outputs.Service = &ref
+			} else {
+				outputs.Services[r.Key] = ref
+			}
 		case CategoryIngress:
 			outputs.Ingress = &ref
 		case CategoryServiceAccount:
@@ -85,14 +113,32 @@ func BuildOutputs(resources []NamedResource) Outputs {
 			outputs.ClusterRoleBinding = &ref
 		case CategoryServiceMonitor:
 			outputs.ServiceMonitor = &ref
+		case CategoryPodMonitor:
+			outputs.PodMonitor = &ref
 		case CategoryPreDeploymentPodMonitor:
 			outputs.PreDeploymentPodMonitor = &ref
+		case CategoryPrometheusRule:
+			outputs.PrometheusRule = &ref
+		case CategoryVirtualService:
+			outputs.VirtualService = &ref
+		case CategoryDestinationRule:
+			outputs.DestinationRule = &ref
+		case CategoryPeerAuthentication:
+			outputs.PeerAuthentication = &ref
+		case CategoryAuthorizationPolicy:
+			outputs.AuthorizationPolicy = &ref
 		case CategoryHTTPRoutes:
 			outputs.HTTPRoutes[r.Key] = ref
 		case CategoryNetworkPolicies:
 			outputs.NetworkPolicies[r.Key] = ref
+		case CategoryCiliumNetworkPolicies:
+			outputs.CiliumNetworkPolicies[r.Key] = ref
 		case CategoryConfigMaps:
 			outputs.ConfigMaps[r.Key] = ref
+		case CategorySecrets:
+			outputs.Secrets[r.Key] = ref
+		case CategoryDashboards:
+			outputs.Dashboards[r.Key] = ref
 		case CategoryPVCs:
 			outputs.PVCs[r.Key] = ref
 		case CategoryCronjobs:
@@ -101,6 +147,18 @@ func BuildOutputs(resources []NamedResource) Outputs {
 			outputs.CronjobPodMonitors[r.Key] = ref
 		case CategoryExternalSecrets:
 			outputs.ExternalSecrets[r.Key] = ref
+		case CategoryPushSecrets:
+			outputs.PushSecrets[r.Key] = ref
+		case CategorySealedSecrets:
+			outputs.SealedSecrets[r.Key] = ref
+		default:
+			if IsBuiltinCategory(r.Category) {
+				continue
+			}
+			if outputs.Custom[string(r.Category)] == nil {
+				outputs.Custom[string(r.Category)] = map[string]Ref{}
+			}
+			outputs.Custom[string(r.Category)][r.Key] = ref
 		}
 	}
 
@@ -63,6 +63,30 @@ func TestBuildOutputs(t *testing.T) {
 				assert.Equal(t, Ref{Name: "service-internal", Namespace: "ns", Kind: "HTTPRoute"}, outputs.HTTPRoutes["internal"])
 			},
 		},
+		"keeps the main Service singular and groups additional Services by key": {
+			Resources: []NamedResource{
+				namedResource(CategoryService, "", "Service", "svc", "ns"),
+				namedResource(CategoryService, "metrics", "Service", "svc-metrics", "ns"),
+			},
+			Asserts: func(t *testing.T, outputs Outputs) {
+				require.NotNil(t, outputs.Service)
+				assert.Equal(t, "svc", outputs.Service.Name)
+				assert.Equal(t, map[string]Ref{"metrics": {Name: "svc-metrics", Namespace: "ns", Kind: "Service"}}, outputs.Services)
+			},
+		},
+		"groups resources of extensions by their category and key": {
+			Resources: []NamedResource{
+				namedResource("backstage", "component", "Component", "svc", "ns"),
+				namedResource("quota", "", "Quota", "svc-quota", "ns"),
+				namedResource(CategoryExternalSecretGenerators, "gen", "Password", "svc-gen", "ns"),
+			},
+			Asserts: func(t *testing.T, outputs Outputs) {
+				assert.Equal(t, map[string]map[string]Ref{
+					"backstage": {"component": {Name: "svc", Namespace: "ns", Kind: "Component"}},
+					"quota":     {"": {Name: "svc-quota", Namespace: "ns", Kind: "Quota"}},
+				}, outputs.Custom)
+			},
+		},
 		"map-keyed categories are non-nil but empty when nothing of that category was created": {
 			Resources: nil,
 			Asserts: func(t *testing.T, outputs Outputs) {
@@ -26,9 +26,7 @@ func CreatePDB(values DeploymentValues) (bool, ResourceCreator) {
 			Spec: *spec,
 		}
 		pdb.Spec.Selector = &metav1.LabelSelector{
-			MatchLabels: map[string]string{
-				"app": serviceName(values.Metadata),
-			},
+			MatchLabels: podSelectorLabels(values),
 		}
 		u, err := toUnstructured(&pdb)
 		if err != nil {
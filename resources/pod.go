@@ -3,7 +3,6 @@ package resources
 import (
 	"fmt"
 
-	"dario.cat/mergo"
 	"github.com/ProRocketeers/yoke-chart/schema"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/utils/ptr"
@@ -18,14 +17,14 @@ func createPodSpec(podValuesExtractor PodValuesExtractor, values DeploymentValue
 
 	initContainers, containers := []corev1.Container{}, []corev1.Container{}
 	for _, initContainer := range podValues.InitContainers {
-		if container, err := createContainer(initContainer, podValues); err != nil {
+		if container, err := createContainer(initContainer, podValues, values.Explanation); err != nil {
 			return corev1.PodSpec{}, fmt.Errorf("creating init container '%v': %v", initContainer.Name, err)
 		} else {
 			initContainers = append(initContainers, container)
 		}
 	}
 	for _, containerInput := range podValues.Containers {
-		if container, err := createContainer(containerInput, podValues); err != nil {
+		if container, err := createContainer(containerInput, podValues, values.Explanation); err != nil {
 			return corev1.PodSpec{}, fmt.Errorf("creating container '%v': %v", containerInput.Name, err)
 		} else {
 			containers = append(containers, container)
@@ -51,7 +50,7 @@ func createPodSpec(podValuesExtractor PodValuesExtractor, values DeploymentValue
 	}
 
 	if podValues.RawPodSpec != nil {
-		if err := mergo.Merge(&podSpec, *podValues.RawPodSpec, mergo.WithOverride); err != nil {
+		if err := values.Explanation.merge(&podSpec, *podValues.RawPodSpec, joinPath(podValues.ValuesPath, "podSpec")); err != nil {
 			return corev1.PodSpec{}, fmt.Errorf("merging raw podSpec: %v", err)
 		}
 	}
@@ -115,6 +114,9 @@ func prepareVolumes(volumes map[string]schema.Volume, metadata Metadata) ([]core
 				},
 				DefaultMode: ptr.To(int32(0444)),
 			}
+			if v.ConfigMap != "" {
+				source.ConfigMap.Name = configMapName(v.ConfigMap, metadata)
+			}
 			if v.Mode != nil {
 				source.ConfigMap.DefaultMode = v.Mode
 			}
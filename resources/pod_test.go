@@ -74,6 +74,30 @@ func TestCreatePodSpec(t *testing.T) {
 				},
 			}
 		},
+		"resolves the configMap shorthand to the generated ConfigMap name": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(dv *DeploymentValues) {
+					dv.Volumes = map[string]schema.Volume{
+						"config": {
+							Type: schema.VolumeTypeConfigMap,
+							Mounts: map[string]schema.VolumeMountList{
+								"main": {
+									{ContainerPath: "/etc/config"},
+								},
+							},
+							Variant: schema.ConfigMapVolume{
+								ConfigMap: "app",
+							},
+						},
+					}
+				},
+				Asserts: func(t *testing.T, podSpec corev1.PodSpec, err error) {
+					require.NoError(t, err)
+					require.Len(t, podSpec.Volumes, 1)
+					assert.Equal(t, "payments-api--component--test-app", podSpec.Volumes[0].ConfigMap.Name)
+				},
+			}
+		},
 		"topologySpreadConstraints and priorityClassName from SchedulingConfig reach the pod spec": func() CaseConfig {
 			return CaseConfig{
 				ValuesTransform: func(dv *DeploymentValues) {
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"maps"
 
-	"dario.cat/mergo"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -28,9 +27,15 @@ func CreatePreDeploymentJob(values DeploymentValues) (bool, ResourceCreator) {
 		jobSpec := batchv1.JobSpec{
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Annotations: j.PodAnnotations,
+					Annotations: func() map[string]string {
+						m := map[string]string{}
+						maps.Copy(m, istioPodAnnotations(values, true))
+						maps.Copy(m, j.PodAnnotations)
+						return m
+					}(),
 					Labels: func() map[string]string {
 						m := map[string]string{}
+						maps.Copy(m, istioPodLabels(values))
 						maps.Copy(m, j.PodLabels)
 						if j.PodMonitor != nil && *j.PodMonitor.Enabled {
 							m["app"] = preDeploymentJobName(j.Metadata)
@@ -43,7 +48,7 @@ func CreatePreDeploymentJob(values DeploymentValues) (bool, ResourceCreator) {
 			},
 		}
 		if j.JobSpec != nil {
-			if err := mergo.Merge(&jobSpec, *j.JobSpec, mergo.WithOverride); err != nil {
+			if err := values.Explanation.merge(&jobSpec, *j.JobSpec, joinPath(j.ValuesPath, "jobSpec")); err != nil {
 				return nil, fmt.Errorf("merging raw jobSpec for pre-deployment job: %v", err)
 			}
 		}
@@ -1,8 +1,13 @@
 package resources
 
 import (
+	"fmt"
+	"slices"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
 )
 
 func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
@@ -10,6 +15,9 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 		if values.ServiceMonitor != nil && *values.ServiceMonitor.Enabled {
 			return true
 		}
+		if values.PodMonitor != nil && *values.PodMonitor.Enabled {
+			return true
+		}
 		if values.PreDeploymentJob != nil && values.PreDeploymentJob.PodMonitor != nil && *values.PreDeploymentJob.PodMonitor.Enabled {
 			return true
 		}
@@ -23,6 +31,7 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 	return create, func(values DeploymentValues) ([]NamedResource, error) {
 		resources := []NamedResource{}
 		if values.ServiceMonitor != nil && *values.ServiceMonitor.Enabled {
+			endpoints := getServiceMonitorEndpoints(values)
 			sm := monitoringv1.ServiceMonitor{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: monitoringv1.SchemeGroupVersion.Identifier(),
@@ -38,12 +47,9 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 						MatchNames: []string{values.Metadata.Namespace},
 					},
 					Selector: metav1.LabelSelector{
-						MatchLabels: map[string]string{
-							"app":               serviceName(values.Metadata),
-							"prometheus-scrape": "true",
-						},
+						MatchLabels: withPrometheusScrapeLabel(podSelectorLabels(values)),
 					},
-					Endpoints: values.ServiceMonitor.Endpoints,
+					Endpoints: endpoints,
 				},
 			}
 			u, err := toUnstructured(&sm)
@@ -52,7 +58,42 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 			}
 			resources = append(resources, NamedResource{Category: CategoryServiceMonitor, Object: u[0]})
 		}
+		if values.PodMonitor != nil && *values.PodMonitor.Enabled {
+			endpoints, err := getPodMetricsEndpoints(values.PodMonitor, values.Containers)
+			if err != nil {
+				return nil, fmt.Errorf("podMonitor: %v", err)
+			}
+			pm := monitoringv1.PodMonitor{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: monitoringv1.SchemeGroupVersion.Identifier(),
+					Kind:       "PodMonitor",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      serviceName(values.Metadata),
+					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
+				},
+				Spec: monitoringv1.PodMonitorSpec{
+					NamespaceSelector: monitoringv1.NamespaceSelector{
+						MatchNames: []string{values.Metadata.Namespace},
+					},
+					Selector: metav1.LabelSelector{
+						MatchLabels: withPrometheusScrapeLabel(podSelectorLabels(values)),
+					},
+					PodMetricsEndpoints: endpoints,
+				},
+			}
+			u, err := toUnstructured(&pm)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryPodMonitor, Object: u[0]})
+		}
 		if values.PreDeploymentJob != nil && values.PreDeploymentJob.PodMonitor != nil && *values.PreDeploymentJob.PodMonitor.Enabled {
+			endpoints, err := getPodMetricsEndpoints(values.PreDeploymentJob.PodMonitor, []Container{values.PreDeploymentJob.Container})
+			if err != nil {
+				return nil, fmt.Errorf("preDeploymentJob podMonitor: %v", err)
+			}
 			pm := monitoringv1.PodMonitor{
 				TypeMeta: metav1.TypeMeta{
 					APIVersion: monitoringv1.SchemeGroupVersion.Identifier(),
@@ -73,7 +114,7 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 							"prometheus-scrape": "true",
 						},
 					},
-					PodMetricsEndpoints: values.PreDeploymentJob.PodMonitor.Endpoints,
+					PodMetricsEndpoints: endpoints,
 				},
 			}
 			u, err := toUnstructured(&pm)
@@ -84,6 +125,10 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 		}
 		for _, cronjob := range values.Cronjobs {
 			if cronjob.PodMonitor != nil && *cronjob.PodMonitor.Enabled {
+				endpoints, err := getPodMetricsEndpoints(cronjob.PodMonitor, []Container{cronjob.Container})
+				if err != nil {
+					return nil, fmt.Errorf("cronjob '%v' podMonitor: %v", cronjob.Name, err)
+				}
 				pm := monitoringv1.PodMonitor{
 					TypeMeta: metav1.TypeMeta{
 						APIVersion: monitoringv1.SchemeGroupVersion.Identifier(),
@@ -104,7 +149,7 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 								"prometheus-scrape": "true",
 							},
 						},
-						PodMetricsEndpoints: cronjob.PodMonitor.Endpoints,
+						PodMetricsEndpoints: endpoints,
 					},
 				}
 				u, err := toUnstructured(&pm)
@@ -117,3 +162,33 @@ func CreatePrometheusMonitors(values DeploymentValues) (bool, ResourceCreator) {
 		return resources, nil
 	}
 }
+
+// getServiceMonitorEndpoints appends an endpoint for every `ports` shorthand entry - the ports are validated to be on
+// the main Service by `ValidateReferences`
+func getServiceMonitorEndpoints(values DeploymentValues) []monitoringv1.Endpoint {
+	endpoints := slices.Clone(values.ServiceMonitor.Endpoints)
+	for _, name := range values.ServiceMonitor.Ports {
+		endpoints = append(endpoints, monitoringv1.Endpoint{Port: name})
+	}
+	return endpoints
+}
+
+// getPodMetricsEndpoints appends an endpoint for every `ports` shorthand entry, scraping the container port by number
+// (container ports only get a name if the port has an explicit `name`)
+func getPodMetricsEndpoints(monitor *schema.PodMonitor, containers []Container) ([]monitoringv1.PodMetricsEndpoint, error) {
+	endpoints := slices.Clone(monitor.Endpoints)
+	containerPorts := getContainerServicePorts(containers)
+	for _, name := range monitor.Ports {
+		i := slices.IndexFunc(containerPorts, func(p containerServicePort) bool { return p.Name == name })
+		if i == -1 {
+			return nil, fmt.Errorf("port %q not found on any container", name)
+		}
+		endpoints = append(endpoints, monitoringv1.PodMetricsEndpoint{PortNumber: ptr.To(containerPorts[i].TargetPort.IntVal)})
+	}
+	return endpoints, nil
+}
+
+func withPrometheusScrapeLabel(labels map[string]string) map[string]string {
+	labels["prometheus-scrape"] = "true"
+	return labels
+}
@@ -162,6 +162,51 @@ func TestPrometheusMonitors(t *testing.T) {
 				assert.Equal(t, ptr.To(true), pm.Spec.PodMetricsEndpoints[0].HonorTimestamps)
 			},
 		},
+		"resolves service monitor ports shorthand, sidecar ports included": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Containers[0].Ports = []schema.Port{{Port: 8080}, {Port: 9090, Name: ptr.To("metrics")}}
+				dv.Containers = append(dv.Containers, Container{
+					Name:  "exporter",
+					Image: Image{Repository: "exporter", Tag: ptr.To("tag")},
+					Ports: []schema.Port{{Port: 9100}},
+				})
+				dv.ServiceMonitor = &schema.ServiceMonitor{
+					Enabled: ptr.To(true),
+					Ports:   []string{"metrics", "other-port-exporter-0"},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				sm := findResourceOrFail[*monitoringv1.ServiceMonitor](t, r, "ServiceMonitor", "service--component--test")
+				assert.Equal(t, []monitoringv1.Endpoint{{Port: "metrics"}, {Port: "other-port-exporter-0"}}, sm.Spec.Endpoints)
+			},
+		},
+		"renders main workload pod monitor scraping unexposed ports": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Containers[0].Ports = []schema.Port{
+					{Port: 8080},
+					{Port: 9090, ContainerPort: ptr.To(9091), Name: ptr.To("metrics"), Expose: ptr.To(false)},
+				}
+				dv.PodMonitor = &schema.PodMonitor{
+					Enabled: ptr.To(true),
+					Ports:   []string{"metrics"},
+					Endpoints: []monitoringv1.PodMetricsEndpoint{
+						{Port: ptr.To("http"), Path: "/stats"},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				pm := findResourceOrFail[*monitoringv1.PodMonitor](t, r, "PodMonitor", "service--component--test")
+
+				assert.Equal(t, map[string]string{
+					"app":               "service--component--test",
+					"prometheus-scrape": "true",
+				}, pm.Spec.Selector.MatchLabels)
+				assert.Equal(t, []monitoringv1.PodMetricsEndpoint{
+					{Port: ptr.To("http"), Path: "/stats"},
+					{PortNumber: ptr.To(int32(9091))},
+				}, pm.Spec.PodMetricsEndpoints)
+			},
+		},
 		"does not render service monitor if not specified - nil config": {
 			ValuesTransform: func(dv *DeploymentValues) {},
 			Asserts: func(t *testing.T, r []NamedResource) {
@@ -326,3 +371,37 @@ func TestPrometheusMonitors(t *testing.T) {
 		})
 	}
 }
+
+func TestPrometheusMonitorsPortsValidation(t *testing.T) {
+	cases := map[string]struct {
+		ValuesTransform func(*DeploymentValues)
+		Error           string
+	}{
+		"unknown pod monitor port": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.PodMonitor = &schema.PodMonitor{Enabled: ptr.To(true), Ports: []string{"nope"}}
+			},
+			Error: `podMonitor: port "nope" not found on any container`,
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := DeploymentValues{
+				Metadata: Metadata{Namespace: "ns", Service: "service", Component: "component", Environment: "test"},
+				Containers: []Container{
+					{
+						Name:  "main",
+						Image: Image{Repository: "image_repository", Tag: ptr.To("image_tag")},
+						Ports: []schema.Port{{Port: 8080}, {Port: 9090, Name: ptr.To("metrics"), Expose: ptr.To(false)}},
+					},
+				},
+			}
+			config.ValuesTransform(&values)
+
+			_, create := CreatePrometheusMonitors(values)
+			_, err := create(values)
+			assert.EqualError(t, err, config.Error)
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package resources
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func CreatePrometheusRules(values DeploymentValues) (bool, ResourceCreator) {
+	return values.PrometheusRules != nil || len(values.SLOs) > 0, func(values DeploymentValues) ([]NamedResource, error) {
+		// SLOs alone still need the object
+		config := values.PrometheusRules
+		if config == nil {
+			config = &schema.PrometheusRules{}
+		}
+
+		groups := []monitoringv1.RuleGroup{}
+		groups = append(groups, config.Groups...)
+		if config.Alerts != nil {
+			rules, err := builtinAlertRules(config.Alerts, values)
+			if err != nil {
+				return nil, err
+			}
+			if len(rules) > 0 {
+				groups = append(groups, monitoringv1.RuleGroup{
+					Name:  fmt.Sprintf("%s.alerts", serviceName(values.Metadata)),
+					Rules: rules,
+				})
+			}
+		}
+
+		sloGroups, err := sloRuleGroups(values)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, sloGroups...)
+
+		rule := monitoringv1.PrometheusRule{
+			TypeMeta: metav1.TypeMeta{
+				APIVersion: monitoringv1.SchemeGroupVersion.Identifier(),
+				Kind:       "PrometheusRule",
+			},
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        serviceName(values.Metadata),
+				Namespace:   values.Metadata.Namespace,
+				Annotations: config.Annotations,
+				Labels:      withCommonLabels(config.Labels, values.Metadata),
+			},
+			Spec: monitoringv1.PrometheusRuleSpec{
+				Groups: groups,
+			},
+		}
+		u, err := toUnstructured(&rule)
+		if err != nil {
+			return nil, err
+		}
+		return []NamedResource{{Category: CategoryPrometheusRule, Object: u[0]}}, nil
+	}
+}
+
+// builtinAlertRules renders the enabled built-in alerts, all queries are scoped to the release's namespace and the
+// names of the objects the chart creates (kube-state-metrics/kubelet series)
+func builtinAlertRules(alerts *schema.BuiltinAlerts, values DeploymentValues) ([]monitoringv1.Rule, error) {
+	ns := values.Metadata.Namespace
+	name := serviceName(values.Metadata)
+	rules := []monitoringv1.Rule{}
+
+	if alerts.PodCrashLooping != nil {
+		podPattern := workloadPodPattern(values)
+		rules = append(rules, builtinAlertRule(values, "PodCrashLooping", alerts.PodCrashLooping, "15m",
+			fmt.Sprintf(`max_over_time(kube_pod_container_status_waiting_reason{namespace=%q, pod=~%q, reason="CrashLoopBackOff"}[5m]) >= 1`, ns, podPattern),
+			"Pod {{ $labels.pod }} is crash looping",
+			"Container {{ $labels.container }} of Pod {{ $labels.namespace }}/{{ $labels.pod }} is in CrashLoopBackOff.",
+		))
+	}
+
+	if alerts.ReplicasUnavailable != nil {
+		expr := fmt.Sprintf(`kube_deployment_status_replicas_unavailable{namespace=%q, deployment=%q} > 0`, ns, name)
+		if values.Kind == "StatefulSet" {
+			expr = fmt.Sprintf(`(kube_statefulset_replicas{namespace=%q, statefulset=%q} - kube_statefulset_status_replicas_ready{namespace=%q, statefulset=%q}) > 0`, ns, name, ns, name)
+		}
+		rules = append(rules, builtinAlertRule(values, "ReplicasUnavailable", alerts.ReplicasUnavailable, "15m",
+			expr,
+			fmt.Sprintf("%s %s has unavailable replicas", values.Kind, name),
+			fmt.Sprintf("%s %s/%s has had {{ $value }} unavailable replicas for longer than expected.", values.Kind, ns, name),
+		))
+	}
+
+	if alerts.HPAMaxedOut != nil {
+		rules = append(rules, builtinAlertRule(values, "HPAMaxedOut", alerts.HPAMaxedOut, "15m",
+			fmt.Sprintf(`kube_horizontalpodautoscaler_status_current_replicas{namespace=%q, horizontalpodautoscaler=%q} >= kube_horizontalpodautoscaler_spec_max_replicas{namespace=%q, horizontalpodautoscaler=%q}`, ns, name, ns, name),
+			fmt.Sprintf("HPA %s is running at max replicas", name),
+			fmt.Sprintf("HPA %s/%s has been running at its maximum number of replicas, consider raising `autoscaling.maxReplicas`.", ns, name),
+		))
+	}
+
+	if alerts.CronjobFailed != nil {
+		for _, cronjob := range values.Cronjobs {
+			// Jobs created by a CronJob are `{cronjob}-{schedule timestamp}` - only the newest one counts, the failed Jobs
+			// kept by `failedJobsHistoryLimit` would keep the alert firing after later runs succeed
+			jobs := fmt.Sprintf(`namespace=%q, job_name=~"%s-[0-9]+"`, ns, cronjobName(cronjob))
+			rules = append(rules, builtinAlertRule(values, "CronjobFailed", alerts.CronjobFailed, "",
+				fmt.Sprintf(
+					`kube_job_failed{%s, condition="true"} == 1 and on (namespace, job_name) (kube_job_status_start_time{%s} == on (namespace) group_left () max by (namespace) (kube_job_status_start_time{%s}))`,
+					jobs, jobs, jobs,
+				),
+				fmt.Sprintf("CronJob %s failed", cronjobName(cronjob)),
+				"Job {{ $labels.namespace }}/{{ $labels.job_name }} failed to complete.",
+			))
+		}
+	}
+
+	if alerts.CronjobNotScheduled != nil {
+		within, err := model.ParseDuration(string(alerts.CronjobNotScheduled.Within))
+		if err != nil {
+			return nil, fmt.Errorf("parsing cronjobNotScheduled.within: %v", err)
+		}
+		for _, cronjob := range values.Cronjobs {
+			rules = append(rules, builtinAlertRule(values, "CronjobNotScheduled", &alerts.CronjobNotScheduled.BuiltinAlert, "",
+				fmt.Sprintf(`time() - kube_cronjob_status_last_schedule_time{namespace=%q, cronjob=%q} > %d`, ns, cronjobName(cronjob), int64(time.Duration(within).Seconds())),
+				fmt.Sprintf("CronJob %s was not scheduled recently", cronjobName(cronjob)),
+				fmt.Sprintf("CronJob %s/%s has not been scheduled within %s.", ns, cronjobName(cronjob), alerts.CronjobNotScheduled.Within),
+			))
+		}
+	}
+
+	if alerts.PreDeploymentJobFailed != nil {
+		rules = append(rules, builtinAlertRule(values, "PreDeploymentJobFailed", alerts.PreDeploymentJobFailed, "",
+			// the Job's Failed condition, failed pods retried within `backoffLimit` don't count
+			fmt.Sprintf(`kube_job_failed{namespace=%q, job_name=%q, condition="true"} == 1`, ns, preDeploymentJobName(values.Metadata)),
+			fmt.Sprintf("Pre-deployment job of %s failed", name),
+			fmt.Sprintf("Job %s/%s failed to complete.", ns, preDeploymentJobName(values.Metadata)),
+		))
+	}
+
+	if alerts.PVCUsage != nil {
+		threshold := 80
+		if alerts.PVCUsage.Threshold != nil {
+			threshold = *alerts.PVCUsage.Threshold
+		}
+		pvcPattern := chartPVCPattern(values)
+		rules = append(rules, builtinAlertRule(values, "PVCUsageHigh", &alerts.PVCUsage.BuiltinAlert, "5m",
+			fmt.Sprintf(`100 * kubelet_volume_stats_used_bytes{namespace=%q, persistentvolumeclaim=~%q} / kubelet_volume_stats_capacity_bytes{namespace=%q, persistentvolumeclaim=~%q} > %d`, ns, pvcPattern, ns, pvcPattern, threshold),
+			"PVC {{ $labels.persistentvolumeclaim }} is running out of space",
+			fmt.Sprintf("PVC {{ $labels.namespace }}/{{ $labels.persistentvolumeclaim }} is more than %d%% full ({{ $value | humanize }}%%).", threshold),
+		))
+	}
+
+	return rules, nil
+}
+
+// chartPVCPattern matches only the PVCs of this release - the chart's own (`{name}--{volume}`) and the claims of the
+// StatefulSet's `volumeClaimTemplates` (`{template}-{name}-{ordinal}`), PromQL regexes are fully anchored
+func chartPVCPattern(values DeploymentValues) string {
+	patterns := []string{}
+	for volumeName := range newPersistentVolumes(values) {
+		patterns = append(patterns, regexp.QuoteMeta(pvcName(volumeName, values.Metadata)))
+	}
+	if values.Kind == "StatefulSet" && values.StatefulSetSpec != nil {
+		for _, template := range values.StatefulSetSpec.VolumeClaimTemplates {
+			patterns = append(patterns, fmt.Sprintf("%s-%s-[0-9]+", regexp.QuoteMeta(template.Name), regexp.QuoteMeta(serviceName(values.Metadata))))
+		}
+	}
+	// the same volume name can be in several pods
+	slices.Sort(patterns)
+	return strings.Join(slices.Compact(patterns), "|")
+}
+
+func builtinAlertRule(values DeploymentValues, alert string, config *schema.BuiltinAlert, defaultFor, expr, summary, description string) monitoringv1.Rule {
+	var forDuration *monitoringv1.Duration
+	if defaultFor != "" {
+		forDuration = ptr.To(monitoringv1.Duration(defaultFor))
+	}
+	if config.For != nil {
+		forDuration = config.For
+	}
+	severity := "warning"
+	if config.Severity != "" {
+		severity = config.Severity
+	}
+
+	labels := map[string]string{
+		"severity":    severity,
+		"service":     values.Metadata.Service,
+		"component":   values.Metadata.Component,
+		"environment": values.Metadata.Environment,
+	}
+	maps.Copy(labels, config.Labels)
+
+	annotations := map[string]string{
+		"summary":     summary,
+		"description": description,
+	}
+	maps.Copy(annotations, config.Annotations)
+
+	return monitoringv1.Rule{
+		Alert:       alert,
+		Expr:        intstr.FromString(expr),
+		For:         forDuration,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+// workloadPodPattern matches the names of the main workload's Pods - Deployment Pods are
+// `{name}-{replicaset hash}-{id}`, StatefulSet Pods `{name}-{ordinal}`
+func workloadPodPattern(values DeploymentValues) string {
+	if values.Kind == "StatefulSet" {
+		return fmt.Sprintf("%s-[0-9]+", serviceName(values.Metadata))
+	}
+	return fmt.Sprintf("%s-[a-z0-9]+-[a-z0-9]+", serviceName(values.Metadata))
+}
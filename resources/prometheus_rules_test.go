@@ -0,0 +1,204 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func TestPrometheusRules(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*DeploymentValues)
+		Asserts         func(*testing.T, *monitoringv1.PrometheusRule)
+	}
+
+	persistentVolume := func() schema.Volume {
+		return schema.Volume{
+			Type:   schema.VolumeTypePersistent,
+			Mounts: map[string]schema.VolumeMountList{"main": {{ContainerPath: "/data"}}},
+			Variant: schema.PersistentVolume{
+				Existing: ptr.To(false),
+				Variant:  schema.PersistentVolumeNew{StorageClassName: "my-sc", Size: "5Gi"},
+			},
+		}
+	}
+
+	findRule := func(t *testing.T, pr *monitoringv1.PrometheusRule, alert string) monitoringv1.Rule {
+		for _, group := range pr.Spec.Groups {
+			for _, rule := range group.Rules {
+				if rule.Alert == alert {
+					return rule
+				}
+			}
+		}
+		t.Fatalf("alert %s not found", alert)
+		return monitoringv1.Rule{}
+	}
+
+	cases := map[string]CaseConfig{
+		"renders raw rule groups as-is": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.PrometheusRules = &schema.PrometheusRules{
+					Labels: map[string]string{"release": "prometheus"},
+					Groups: []monitoringv1.RuleGroup{
+						{
+							Name: "custom",
+							Rules: []monitoringv1.Rule{
+								{Alert: "Custom", Expr: intstr.FromString("up == 0")},
+							},
+						},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, pr *monitoringv1.PrometheusRule) {
+				assert.Equal(t, "service--component--test", pr.Name)
+				assert.Subset(t, pr.Labels, map[string]string{"release": "prometheus", "app": "service--component--test"})
+				require.Len(t, pr.Spec.Groups, 1)
+				assert.Equal(t, "custom", pr.Spec.Groups[0].Name)
+				assert.Equal(t, "up == 0", pr.Spec.Groups[0].Rules[0].Expr.String())
+			},
+		},
+		"renders built-in workload alerts with defaults": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.PrometheusRules = &schema.PrometheusRules{
+					Alerts: &schema.BuiltinAlerts{
+						PodCrashLooping:     &schema.BuiltinAlert{},
+						ReplicasUnavailable: &schema.BuiltinAlert{Severity: "critical", For: ptr.To(monitoringv1.Duration("5m"))},
+						HPAMaxedOut:         &schema.BuiltinAlert{Labels: map[string]string{"team": "a"}},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, pr *monitoringv1.PrometheusRule) {
+				require.Len(t, pr.Spec.Groups, 1)
+				assert.Equal(t, "service--component--test.alerts", pr.Spec.Groups[0].Name)
+
+				crash := findRule(t, pr, "PodCrashLooping")
+				assert.Contains(t, crash.Expr.String(), `namespace="ns", pod=~"service--component--test-[a-z0-9]+-[a-z0-9]+"`)
+				assert.Equal(t, monitoringv1.Duration("15m"), *crash.For)
+				assert.Equal(t, map[string]string{
+					"severity":    "warning",
+					"service":     "service",
+					"component":   "component",
+					"environment": "test",
+				}, crash.Labels)
+
+				replicas := findRule(t, pr, "ReplicasUnavailable")
+				assert.Equal(t, `kube_deployment_status_replicas_unavailable{namespace="ns", deployment="service--component--test"} > 0`, replicas.Expr.String())
+				assert.Equal(t, monitoringv1.Duration("5m"), *replicas.For)
+				assert.Equal(t, "critical", replicas.Labels["severity"])
+
+				hpa := findRule(t, pr, "HPAMaxedOut")
+				assert.Contains(t, hpa.Expr.String(), `horizontalpodautoscaler="service--component--test"`)
+				assert.Equal(t, "a", hpa.Labels["team"])
+			},
+		},
+		"uses StatefulSet series for StatefulSet workloads": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Kind = "StatefulSet"
+				dv.PrometheusRules = &schema.PrometheusRules{
+					Alerts: &schema.BuiltinAlerts{
+						PodCrashLooping:     &schema.BuiltinAlert{},
+						ReplicasUnavailable: &schema.BuiltinAlert{},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, pr *monitoringv1.PrometheusRule) {
+				assert.Contains(t, findRule(t, pr, "PodCrashLooping").Expr.StrVal, `pod=~"service--component--test-[0-9]+"`)
+				assert.Contains(t, findRule(t, pr, "ReplicasUnavailable").Expr.StrVal, `kube_statefulset_status_replicas_ready{namespace="ns", statefulset="service--component--test"}`)
+			},
+		},
+		"renders job and PVC alerts": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Cronjobs = []Cronjob{{Metadata: dv.Metadata, Name: "cleanup", Volumes: map[string]schema.Volume{"data": persistentVolume()}}}
+				dv.PreDeploymentJob = &PreDeploymentJob{Metadata: dv.Metadata}
+				dv.Volumes = map[string]schema.Volume{"data": persistentVolume()}
+				dv.PrometheusRules = &schema.PrometheusRules{
+					Alerts: &schema.BuiltinAlerts{
+						CronjobFailed:          &schema.BuiltinAlert{},
+						CronjobNotScheduled:    &schema.CronjobNotScheduledAlert{Within: "1d"},
+						PreDeploymentJobFailed: &schema.BuiltinAlert{Annotations: map[string]string{"runbook_url": "https://example.com"}},
+						PVCUsage:               &schema.PVCUsageAlert{Threshold: ptr.To(90)},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, pr *monitoringv1.PrometheusRule) {
+				failed := findRule(t, pr, "CronjobFailed")
+				jobs := `namespace="ns", job_name=~"cleanup--test-[0-9]+"`
+				assert.Equal(t,
+					`kube_job_failed{`+jobs+`, condition="true"} == 1 and on (namespace, job_name) (kube_job_status_start_time{`+jobs+`} == on (namespace) group_left () max by (namespace) (kube_job_status_start_time{`+jobs+`}))`,
+					failed.Expr.StrVal,
+				)
+				assert.Nil(t, failed.For)
+
+				assert.Equal(t,
+					`time() - kube_cronjob_status_last_schedule_time{namespace="ns", cronjob="cleanup--test"} > 86400`,
+					findRule(t, pr, "CronjobNotScheduled").Expr.StrVal,
+				)
+
+				pdj := findRule(t, pr, "PreDeploymentJobFailed")
+				assert.Equal(t,
+					`kube_job_failed{namespace="ns", job_name="service--component--test--pre-deploy", condition="true"} == 1`,
+					pdj.Expr.StrVal,
+				)
+				assert.Equal(t, "https://example.com", pdj.Annotations["runbook_url"])
+				assert.NotEmpty(t, pdj.Annotations["summary"])
+
+				pvc := findRule(t, pr, "PVCUsageHigh")
+				assert.Contains(t, pvc.Expr.String(), `persistentvolumeclaim=~"service--component--test--data"`)
+				assert.Contains(t, pvc.Expr.String(), "> 90")
+			},
+		},
+		"scopes the PVC alert to the StatefulSet's claims": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.Kind = "StatefulSet"
+				dv.Volumes = map[string]schema.Volume{"cache": persistentVolume()}
+				dv.StatefulSetSpec = &appsv1.StatefulSetSpec{
+					VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{ObjectMeta: metav1.ObjectMeta{Name: "data"}}},
+				}
+				dv.PrometheusRules = &schema.PrometheusRules{
+					Alerts: &schema.BuiltinAlerts{PVCUsage: &schema.PVCUsageAlert{}},
+				}
+			},
+			Asserts: func(t *testing.T, pr *monitoringv1.PrometheusRule) {
+				pvc := findRule(t, pr, "PVCUsageHigh")
+				assert.Contains(t, pvc.Expr.String(), `persistentvolumeclaim=~"data-service--component--test-[0-9]+|service--component--test--cache"`)
+				assert.Contains(t, pvc.Expr.String(), "> 80")
+			},
+		},
+	}
+
+	base := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Kind: "Deployment",
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := DeploymentValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+
+			config.ValuesTransform(&values)
+
+			shouldCreate, create := CreatePrometheusRules(values)
+			require.True(t, shouldCreate)
+			resources, err := create(values)
+			require.NoError(t, err)
+
+			config.Asserts(t, fromUnstructuredOrPanic[*monitoringv1.PrometheusRule](resources[0]))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	esv1alpha1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreatePushSecrets(values DeploymentValues) (bool, ResourceCreator) {
+	return len(values.PushSecrets) > 0, func(values DeploymentValues) ([]NamedResource, error) {
+		resources := []NamedResource{}
+		for name, push := range sortedMap(values.PushSecrets) {
+			sourceSecret := push.Secret
+			keys := push.Keys
+			if push.DBUser != "" {
+				sourceSecret = dbCredentialsSecretName(push.DBUser, values.DB.ClusterName)
+				if keys == nil {
+					keys = map[string]*string{"username": nil, "password": nil}
+				}
+			}
+
+			// without keys the whole Secret is pushed as a single remote secret
+			data := []esv1alpha1.PushSecretData{}
+			if len(keys) == 0 {
+				data = append(data, esv1alpha1.PushSecretData{
+					Match: esv1alpha1.PushSecretMatch{
+						RemoteRef: esv1alpha1.PushSecretRemoteRef{RemoteKey: push.RemotePath},
+					},
+				})
+			}
+			for secretKey, property := range sortedMap(keys) {
+				remoteRef := esv1alpha1.PushSecretRemoteRef{RemoteKey: push.RemotePath, Property: secretKey}
+				if property != nil {
+					remoteRef.Property = *property
+				}
+				data = append(data, esv1alpha1.PushSecretData{
+					Match: esv1alpha1.PushSecretMatch{SecretKey: secretKey, RemoteRef: remoteRef},
+				})
+			}
+
+			spec := esv1alpha1.PushSecretSpec{
+				RefreshInterval: &metav1.Duration{Duration: 1 * time.Minute},
+				SecretStoreRefs: []esv1alpha1.PushSecretStoreRef{
+					{Name: push.SecretStore.Name, Kind: push.SecretStore.Kind},
+				},
+				UpdatePolicy:   esv1alpha1.PushSecretUpdatePolicyReplace,
+				DeletionPolicy: esv1alpha1.PushSecretDeletionPolicyNone,
+				Selector: esv1alpha1.PushSecretSelector{
+					Secret: &esv1alpha1.PushSecretSecret{Name: sourceSecret},
+				},
+				Data: data,
+			}
+			if push.RefreshInterval != nil {
+				spec.RefreshInterval = push.RefreshInterval
+			}
+			if push.UpdatePolicy != nil {
+				spec.UpdatePolicy = *push.UpdatePolicy
+			}
+			if push.DeletionPolicy != nil {
+				spec.DeletionPolicy = *push.DeletionPolicy
+			}
+
+			pushSecret := esv1alpha1.PushSecret{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: esv1alpha1.SchemeGroupVersion.Identifier(),
+					Kind:       "PushSecret",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      pushSecretName(name, values.Metadata),
+					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
+				},
+				Spec: spec,
+			}
+			u, err := toUnstructured(&pushSecret)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryPushSecrets, Key: name, Object: u[0]})
+		}
+		return resources, nil
+	}
+}
+
+// the Postgres operator stores the credentials of every user in `{user}.{cluster}.credentials.postgresql.acid.zalan.do`,
+// underscores in the user name become dashes
+func dbCredentialsSecretName(user, clusterName string) string {
+	return fmt.Sprintf("%s.%s.credentials.postgresql.acid.zalan.do", strings.ReplaceAll(user, "_", "-"), clusterName)
+}
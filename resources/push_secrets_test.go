@@ -0,0 +1,110 @@
+package resources
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ProRocketeers/yoke-chart/resources/postgresql"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	esv1alpha1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1alpha1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestPushSecrets(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*DeploymentValues)
+		Asserts         func(*testing.T, []NamedResource)
+	}
+
+	store := es.SecretStoreRef{Name: "vault", Kind: "ClusterSecretStore"}
+
+	cases := map[string]CaseConfig{
+		"pushes the DB credentials of a user": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.DB = &schema.Database{
+					ClusterName: "pg-cluster",
+					Users:       map[string]postgresql.UserFlags{"app_user": {}},
+				}
+				dv.PushSecrets = map[string]schema.PushSecret{
+					"db": {SecretStore: store, RemotePath: "teams/payments/db", DBUser: "app_user"},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				push := findResourceOrFail[*esv1alpha1.PushSecret](t, r, "PushSecret", "service--component--test--push-db")
+				assert.Equal(t, "external-secrets.io/v1alpha1", push.APIVersion)
+				assert.Equal(t, []esv1alpha1.PushSecretStoreRef{{Name: "vault", Kind: "ClusterSecretStore"}}, push.Spec.SecretStoreRefs)
+				assert.Equal(t, "app-user.pg-cluster.credentials.postgresql.acid.zalan.do", push.Spec.Selector.Secret.Name)
+				assert.Equal(t, []esv1alpha1.PushSecretData{
+					{Match: esv1alpha1.PushSecretMatch{SecretKey: "password", RemoteRef: esv1alpha1.PushSecretRemoteRef{RemoteKey: "teams/payments/db", Property: "password"}}},
+					{Match: esv1alpha1.PushSecretMatch{SecretKey: "username", RemoteRef: esv1alpha1.PushSecretRemoteRef{RemoteKey: "teams/payments/db", Property: "username"}}},
+				}, push.Spec.Data)
+				assert.Equal(t, esv1alpha1.PushSecretUpdatePolicyReplace, push.Spec.UpdatePolicy)
+				assert.Equal(t, esv1alpha1.PushSecretDeletionPolicyNone, push.Spec.DeletionPolicy)
+				assert.Equal(t, time.Minute, push.Spec.RefreshInterval.Duration)
+			},
+		},
+		"pushes selected keys of a Secret": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.PushSecrets = map[string]schema.PushSecret{
+					"tls": {
+						SecretStore:    store,
+						RemotePath:     "teams/payments/tls",
+						Secret:         "payments-tls",
+						Keys:           map[string]*string{"tls.crt": ptr.To("certificate"), "tls.key": nil},
+						UpdatePolicy:   ptr.To(esv1alpha1.PushSecretUpdatePolicyIfNotExists),
+						DeletionPolicy: ptr.To(esv1alpha1.PushSecretDeletionPolicyDelete),
+					},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				push := findResourceOrFail[*esv1alpha1.PushSecret](t, r, "PushSecret", "service--component--test--push-tls")
+				assert.Equal(t, "payments-tls", push.Spec.Selector.Secret.Name)
+				require.Len(t, push.Spec.Data, 2)
+				assert.Equal(t, "certificate", push.Spec.Data[0].Match.RemoteRef.Property)
+				assert.Equal(t, "tls.key", push.Spec.Data[1].Match.RemoteRef.Property)
+				assert.Equal(t, esv1alpha1.PushSecretUpdatePolicyIfNotExists, push.Spec.UpdatePolicy)
+				assert.Equal(t, esv1alpha1.PushSecretDeletionPolicyDelete, push.Spec.DeletionPolicy)
+			},
+		},
+		"pushes the whole Secret without keys": {
+			ValuesTransform: func(dv *DeploymentValues) {
+				dv.PushSecrets = map[string]schema.PushSecret{
+					"tls": {SecretStore: store, RemotePath: "teams/payments/tls", Secret: "payments-tls"},
+				}
+			},
+			Asserts: func(t *testing.T, r []NamedResource) {
+				push := findResourceOrFail[*esv1alpha1.PushSecret](t, r, "PushSecret", "service--component--test--push-tls")
+				assert.Equal(t, []esv1alpha1.PushSecretData{
+					{Match: esv1alpha1.PushSecretMatch{RemoteRef: esv1alpha1.PushSecretRemoteRef{RemoteKey: "teams/payments/tls"}}},
+				}, push.Spec.Data)
+			},
+		},
+	}
+
+	base := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := base
+			config.ValuesTransform(&values)
+
+			shouldCreate, create := CreatePushSecrets(values)
+			require.True(t, shouldCreate)
+
+			resources, err := create(values)
+			require.NoError(t, err)
+
+			config.Asserts(t, resources)
+		})
+	}
+}
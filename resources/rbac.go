@@ -28,6 +28,7 @@ func CreateRBAC(values DeploymentValues) (bool, ResourceCreator) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      roleName,
 					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
 				},
 				Rules: sa.AdditionalRole.Rules,
 			}
@@ -39,6 +40,7 @@ func CreateRBAC(values DeploymentValues) (bool, ResourceCreator) {
 				ObjectMeta: metav1.ObjectMeta{
 					Name:      roleBindingName,
 					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
 				},
 				Subjects: []rbacv1.Subject{
 					{
@@ -76,7 +78,8 @@ func CreateRBAC(values DeploymentValues) (bool, ResourceCreator) {
 					Kind:       "ClusterRole",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name: roleName,
+					Name:   roleName,
+					Labels: commonLabels(values.Metadata),
 				},
 				Rules: sa.AdditionalClusterRole.Rules,
 			}
@@ -86,7 +89,8 @@ func CreateRBAC(values DeploymentValues) (bool, ResourceCreator) {
 					Kind:       "ClusterRoleBinding",
 				},
 				ObjectMeta: metav1.ObjectMeta{
-					Name: roleBindingName,
+					Name:   roleBindingName,
+					Labels: commonLabels(values.Metadata),
 				},
 				Subjects: []rbacv1.Subject{
 					{
@@ -0,0 +1,213 @@
+package resources
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// ValidateReferences checks the free-form references between containers, volumes and the objects the chart generates,
+// which would otherwise only fail once applied to the cluster (or worse, silently point at nothing)
+func ValidateReferences(values DeploymentValues) error {
+	// 1. configMap volumes referencing the chart's own `configMaps`
+	if err := validateConfigMapVolumes(values); err != nil {
+		return err
+	}
+
+	// 2. probes referencing a port by name need the container to have such named port
+	if err := validateProbePorts(values); err != nil {
+		return err
+	}
+
+	// 3. ServiceMonitor endpoints can only scrape ports of the main Service
+	if err := validateServiceMonitorPorts(values); err != nil {
+		return err
+	}
+
+	// 4. Ingress backends pointing to the chart's Services must use one of their ports
+	if err := validateIngressBackends(values); err != nil {
+		return err
+	}
+	return nil
+}
+
+func validateConfigMapVolumes(values DeploymentValues) error {
+	// ConfigMap name => key of `configMaps`
+	generatedNames := map[string]string{}
+	for name := range values.ConfigMaps {
+		generatedNames[configMapName(name, values.Metadata)] = name
+	}
+
+	validate := func(owner string, volumes map[string]schema.Volume) error {
+		for volumeName, volume := range sortedMap(volumes) {
+			v, ok := volume.Variant.(schema.ConfigMapVolume)
+			if !ok {
+				continue
+			}
+			key := v.ConfigMap
+			if key != "" {
+				if _, ok := values.ConfigMaps[key]; !ok {
+					return fmt.Errorf("%svolume '%v' references configMap '%v', which is not defined in `configMaps`", owner, volumeName, key)
+				}
+			} else if _, ok := values.ConfigMaps[v.ConfigMapName]; ok {
+				return fmt.Errorf(
+					"%svolume '%v' has configMapName '%v', but the ConfigMap from `configMaps` is named '%v' - use `configMap: %v` instead",
+					owner, volumeName, v.ConfigMapName, configMapName(v.ConfigMapName, values.Metadata), v.ConfigMapName,
+				)
+			} else if key, ok = generatedNames[v.ConfigMapName]; !ok {
+				// not one of ours, nothing more to check
+				continue
+			}
+
+			for filePath, configMapKey := range sortedMap(v.Items) {
+				itemKey := filePath
+				if configMapKey != nil {
+					itemKey = *configMapKey
+				}
+				if _, ok := values.ConfigMaps[key][itemKey]; !ok {
+					return fmt.Errorf("%svolume '%v' mounts key '%v', which is not in configMap '%v'", owner, volumeName, itemKey, key)
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := validate("", values.Volumes); err != nil {
+		return err
+	}
+	if values.PreDeploymentJob != nil {
+		if err := validate("pre-deployment job ", values.PreDeploymentJob.Volumes); err != nil {
+			return err
+		}
+	}
+	for _, cronjob := range values.Cronjobs {
+		if err := validate(fmt.Sprintf("cronjob '%v' ", cronjob.Name), cronjob.Volumes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateProbePorts(values DeploymentValues) error {
+	for _, container := range getAllContainers(values) {
+		portNames := []string{}
+		for _, port := range getPorts(container) {
+			portNames = append(portNames, port.Name)
+		}
+		if container.ContainerSpec != nil {
+			for _, port := range container.ContainerSpec.Ports {
+				portNames = append(portNames, port.Name)
+			}
+		}
+
+		probes := map[string]*corev1.Probe{
+			"readiness": container.ReadinessProbe,
+			"liveness":  container.LivenessProbe,
+			"startup":   container.StartupProbe,
+		}
+		for probeName, probe := range sortedMap(probes) {
+			if probe == nil {
+				continue
+			}
+			var port *intstr.IntOrString
+			if probe.HTTPGet != nil {
+				port = &probe.HTTPGet.Port
+			} else if probe.TCPSocket != nil {
+				port = &probe.TCPSocket.Port
+			}
+			if port != nil && port.Type == intstr.String && !slices.Contains(portNames, port.StrVal) {
+				return fmt.Errorf("%v probe of container '%v' uses port '%v', which is not a named port of the container", probeName, container.Name, port.StrVal)
+			}
+		}
+	}
+	return nil
+}
+
+func validateServiceMonitorPorts(values DeploymentValues) error {
+	if values.ServiceMonitor == nil || !*values.ServiceMonitor.Enabled {
+		return nil
+	}
+	ports := mainServicePorts(values)
+	isServicePort := func(name string) bool {
+		return slices.ContainsFunc(ports, func(p corev1.ServicePort) bool { return p.Name == name })
+	}
+	for _, endpoint := range values.ServiceMonitor.Endpoints {
+		if endpoint.Port != "" && !isServicePort(endpoint.Port) {
+			return fmt.Errorf("serviceMonitor endpoint port '%v' is not a port of the Service", endpoint.Port)
+		}
+	}
+	// otherwise the ServiceMonitor would just silently never scrape anything
+	for _, name := range values.ServiceMonitor.Ports {
+		if !isServicePort(name) {
+			return fmt.Errorf("serviceMonitor port '%v' is not a port of the Service", name)
+		}
+	}
+	return nil
+}
+
+func validateIngressBackends(values DeploymentValues) error {
+	if values.Ingress == nil || !*values.Ingress.Enabled {
+		return nil
+	}
+	// Service name => its ports, only the chart's own Services can be checked
+	services := map[string][]corev1.ServicePort{
+		serviceName(values.Metadata): mainServicePorts(values),
+	}
+	for name, config := range values.Services {
+		ports, err := getAdditionalServicePorts(values, config)
+		if err != nil {
+			// reported when creating the Service
+			continue
+		}
+		if config.RawSpec != nil && len(config.RawSpec.Ports) > 0 {
+			ports = config.RawSpec.Ports
+		}
+		services[additionalServiceName(name, values.Metadata)] = ports
+	}
+
+	backends := []*networkingv1.IngressBackend{values.Ingress.DefaultBackend}
+	for _, rule := range values.Ingress.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			backends = append(backends, &rule.HTTP.Paths[i].Backend)
+		}
+	}
+	for _, backend := range backends {
+		if backend == nil || backend.Service == nil {
+			continue
+		}
+		ports, ok := services[backend.Service.Name]
+		if !ok {
+			continue
+		}
+		port := backend.Service.Port
+		found := slices.ContainsFunc(ports, func(p corev1.ServicePort) bool {
+			if port.Name != "" {
+				return p.Name == port.Name
+			}
+			return p.Port == port.Number
+		})
+		if !found {
+			portName := port.Name
+			if portName == "" {
+				portName = fmt.Sprint(port.Number)
+			}
+			return fmt.Errorf("ingress backend uses port '%v' of Service '%v', which doesn't have it", portName, backend.Service.Name)
+		}
+	}
+	return nil
+}
+
+// the ports of the main Service, `serviceConfig` ports replace the generated ones
+func mainServicePorts(values DeploymentValues) []corev1.ServicePort {
+	if values.Service.RawSpec != nil && len(values.Service.RawSpec.Ports) > 0 {
+		return values.Service.RawSpec.Ports
+	}
+	return getServicePorts(values)
+}
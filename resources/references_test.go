@@ -0,0 +1,246 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+func TestValidateReferences(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, error)
+	}
+
+	configMapVolume := func(variant schema.ConfigMapVolume) schema.Volume {
+		return schema.Volume{
+			Type:    schema.VolumeTypeConfigMap,
+			Mounts:  map[string]schema.VolumeMountList{"main": {{ContainerPath: "/etc/config"}}},
+			Variant: variant,
+		}
+	}
+	ingressTo := func(service string, port networkingv1.ServiceBackendPort) *schema.Ingress {
+		return &schema.Ingress{
+			Enabled: ptr.To(true),
+			IngressSpec: networkingv1.IngressSpec{
+				Rules: []networkingv1.IngressRule{{
+					Host: "example.com",
+					IngressRuleValue: networkingv1.IngressRuleValue{HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     "/",
+							PathType: ptr.To(networkingv1.PathTypePrefix),
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{Name: service, Port: port},
+							},
+						}},
+					}},
+				}},
+			},
+		}
+	}
+
+	cases := map[string]CaseConfig{
+		"passes the defaults": {
+			ValuesTransform: func(iv *schema.InputValues) {},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes configMap shorthand with existing keys": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ConfigMaps = map[string]map[string]string{"app": {"config.yaml": "foo: bar"}}
+				iv.Volumes = map[string]schema.Volume{
+					"config": configMapVolume(schema.ConfigMapVolume{
+						ConfigMap: "app",
+						Items:     map[string]*string{"app.yaml": ptr.To("config.yaml")},
+					}),
+				}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"fails configMap shorthand to an undefined ConfigMap": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Volumes = map[string]schema.Volume{"config": configMapVolume(schema.ConfigMapVolume{ConfigMap: "app"})}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "volume 'config' references configMap 'app', which is not defined in `configMaps`")
+			},
+		},
+		"fails configMapName set to a configMaps key": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ConfigMaps = map[string]map[string]string{"app": {"config.yaml": "foo: bar"}}
+				iv.Volumes = map[string]schema.Volume{"config": configMapVolume(schema.ConfigMapVolume{ConfigMapName: "app"})}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "is named 'service--component--test-app' - use `configMap: app` instead")
+			},
+		},
+		"fails missing key of a generated ConfigMap": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ConfigMaps = map[string]map[string]string{"app": {"config.yaml": "foo: bar"}}
+				iv.Cronjobs = []schema.Cronjob{{
+					Name:      "job",
+					Schedule:  "* * * * *",
+					Container: schema.Container{Image: schema.Image{Repository: "job", Tag: ptr.To("1")}},
+					Volumes: map[string]schema.Volume{
+						"config": {
+							Type:   schema.VolumeTypeConfigMap,
+							Mounts: map[string]schema.VolumeMountList{"job": {{ContainerPath: "/etc/config"}}},
+							Variant: schema.ConfigMapVolume{
+								ConfigMapName: "service--component--test-app",
+								Items:         map[string]*string{"other.yaml": nil},
+							},
+						},
+					},
+				}}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "cronjob 'job' volume 'config' mounts key 'other.yaml', which is not in configMap 'app'")
+			},
+		},
+		"passes external configMapName": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Volumes = map[string]schema.Volume{
+					"config": configMapVolume(schema.ConfigMapVolume{ConfigMapName: "shared", Items: map[string]*string{"a": nil}}),
+				}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes probe with a named port": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Ports = []schema.Port{{Port: 8080, Name: ptr.To("http")}}
+				iv.ReadinessProbe = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+					HTTPGet: &corev1.HTTPGetAction{Path: "/health", Port: intstr.FromString("http")},
+				}}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"fails probe with an unknown port name": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.LivenessProbe = &corev1.Probe{ProbeHandler: corev1.ProbeHandler{
+					TCPSocket: &corev1.TCPSocketAction{Port: intstr.FromString("http")},
+				}}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "liveness probe of container 'main' uses port 'http', which is not a named port of the container")
+			},
+		},
+		"fails serviceMonitor endpoint with an unknown port": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ServiceMonitor = &schema.ServiceMonitor{
+					Enabled:   ptr.To(true),
+					Endpoints: []monitoringv1.Endpoint{{Port: "metrics"}},
+				}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "serviceMonitor endpoint port 'metrics' is not a port of the Service")
+			},
+		},
+		"fails serviceMonitor port which is not exposed on the Service": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Ports = []schema.Port{{Port: 8080}, {Port: 9090, Name: ptr.To("metrics"), Expose: ptr.To(false)}}
+				iv.ServiceMonitor = &schema.ServiceMonitor{Enabled: ptr.To(true), Ports: []string{"metrics"}}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "serviceMonitor port 'metrics' is not a port of the Service")
+			},
+		},
+		"passes serviceMonitor port from serviceConfig ports": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ServiceConfig = &schema.ServiceConfig{ServiceSpec: corev1.ServiceSpec{
+					Ports: []corev1.ServicePort{{Name: "metrics", Port: 9090}},
+				}}
+				iv.ServiceMonitor = &schema.ServiceMonitor{Enabled: ptr.To(true), Ports: []string{"metrics"}}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes serviceMonitor endpoint with a generated port name": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.ServiceMonitor = &schema.ServiceMonitor{
+					Enabled:   ptr.To(true),
+					Endpoints: []monitoringv1.Endpoint{{Port: "main-port"}},
+				}
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"passes ingress backend with a port of the main Service": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Ingress = ingressTo("service--component--test", networkingv1.ServiceBackendPort{Number: 8080})
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+		"fails ingress backend with an unknown port of the main Service": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Ingress = ingressTo("service--component--test", networkingv1.ServiceBackendPort{Name: "http"})
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "ingress backend uses port 'http' of Service 'service--component--test', which doesn't have it")
+			},
+		},
+		"fails ingress backend with an unknown port of an additional Service": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Services = map[string]schema.AdditionalService{"admin": {Ports: []string{"main-port"}}}
+				iv.Ingress = ingressTo("service--component--test-admin", networkingv1.ServiceBackendPort{Number: 9090})
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.ErrorContains(t, err, "ingress backend uses port '9090' of Service 'service--component--test-admin'")
+			},
+		},
+		"passes ingress backend to a foreign Service": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Ingress = ingressTo("other", networkingv1.ServiceBackendPort{Name: "http"})
+			},
+			Asserts: func(t *testing.T, err error) {
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("image_tag"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			require.NoError(t, err)
+			config.Asserts(t, ValidateReferences(deploymentValues))
+		})
+	}
+}
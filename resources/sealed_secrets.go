@@ -0,0 +1,79 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/ProRocketeers/yoke-chart/resources/sealedsecrets"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateSealedSecrets(values DeploymentValues) (bool, ResourceCreator) {
+	create := false
+	for _, container := range getAllContainers(values) {
+		if len(container.SealedSecrets) > 0 {
+			create = true
+			break
+		}
+	}
+	return create, func(values DeploymentValues) ([]NamedResource, error) {
+		resources := []NamedResource{}
+		containers := getAllContainers(values)
+		if name, ok := duplicateSecretName(containers, values.Metadata); ok {
+			return nil, fmt.Errorf("duplicate sealed secret names in multiple containers: %s", name)
+		}
+		for _, container := range containers {
+			for _, definition := range container.SealedSecrets {
+				name := sealedSecretName(definition, values.Metadata)
+
+				// the controller checks the scope on the SealedSecret, the template only describes the unsealed Secret
+				var annotations map[string]string
+				switch definition.Scope {
+				case "namespace-wide":
+					annotations = map[string]string{sealedsecrets.NamespaceWideAnnotation: "true"}
+				case "cluster-wide":
+					annotations = map[string]string{sealedsecrets.ClusterWideAnnotation: "true"}
+				}
+
+				template := sealedsecrets.SecretTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: values.Metadata.Namespace,
+						Labels:    commonLabels(values.Metadata),
+					},
+					Type: corev1.SecretTypeOpaque,
+				}
+				if definition.Template != nil {
+					if definition.Template.Type != "" {
+						template.Type = definition.Template.Type
+					}
+					template.Labels = withCommonLabels(definition.Template.Labels, values.Metadata)
+					template.Annotations = definition.Template.Annotations
+				}
+
+				sealedSecret := sealedsecrets.SealedSecret{
+					TypeMeta: metav1.TypeMeta{
+						APIVersion: sealedsecrets.SchemeGroupVersion.Identifier(),
+						Kind:       "SealedSecret",
+					},
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Namespace:   values.Metadata.Namespace,
+						Labels:      commonLabels(values.Metadata),
+						Annotations: annotations,
+					},
+					Spec: sealedsecrets.SealedSecretSpec{
+						Template:      template,
+						EncryptedData: definition.EncryptedData,
+					},
+				}
+				u, err := toUnstructured(&sealedSecret)
+				if err != nil {
+					return nil, err
+				}
+				resources = append(resources, NamedResource{Category: CategorySealedSecrets, Key: name, Object: u[0]})
+			}
+		}
+		return resources, nil
+	}
+}
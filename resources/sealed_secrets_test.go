@@ -0,0 +1,162 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/resources/sealedsecrets"
+	"github.com/ProRocketeers/yoke-chart/schema"
+	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestSealedSecrets(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, DeploymentValues, []NamedResource, error)
+	}
+
+	sealed := func(name string) schema.SealedSecretDefinition {
+		return schema.SealedSecretDefinition{
+			Name:          name,
+			EncryptedData: map[string]string{"PASSWORD": "AgBy3i4OJSWK"},
+		}
+	}
+
+	cases := map[string]CaseConfig{
+		"renders a strict SealedSecret mounted through envFrom": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.SealedSecrets = []schema.SealedSecretDefinition{sealed("db")}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				require.NoError(t, err)
+				require.Len(t, r, 1)
+				secret := findResourceOrFail[*sealedsecrets.SealedSecret](t, r, "SealedSecret", "service--component--test--sealed--db")
+				assert.Empty(t, secret.Annotations)
+				assert.Equal(t, map[string]string{"PASSWORD": "AgBy3i4OJSWK"}, secret.Spec.EncryptedData)
+				assert.Equal(t, "service--component--test--sealed--db", secret.Spec.Template.Name)
+				assert.Equal(t, corev1.SecretTypeOpaque, secret.Spec.Template.Type)
+
+				envs, envsFrom := getEnvs(dv.Containers[0], dv.Metadata)
+				assert.Empty(t, envs)
+				assert.Equal(t, []corev1.EnvFromSource{{
+					SecretRef: &corev1.SecretEnvSource{
+						LocalObjectReference: corev1.LocalObjectReference{Name: "service--component--test--sealed--db"},
+					},
+				}}, envsFrom)
+
+				assert.Equal(t, "service--component--test--sealed--db", BuildOutputs(r).SealedSecrets["service--component--test--sealed--db"].Name)
+			},
+		},
+		"maps selected keys to envs": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				secret := sealed("db")
+				secret.Mapping = schema.SecretMapping{"DB_PASSWORD": ptr.To("PASSWORD"), "PASSWORD": nil}
+				iv.SealedSecrets = []schema.SealedSecretDefinition{secret}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				require.NoError(t, err)
+				envs, envsFrom := getEnvs(dv.Containers[0], dv.Metadata)
+				assert.Empty(t, envsFrom)
+				require.Len(t, envs, 2)
+				assert.Equal(t, "DB_PASSWORD", envs[0].Name)
+				assert.Equal(t, "PASSWORD", envs[0].ValueFrom.SecretKeyRef.Key)
+				assert.Equal(t, "PASSWORD", envs[1].ValueFrom.SecretKeyRef.Key)
+			},
+		},
+		"sets the scope annotations": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				namespaceWide, clusterWide := sealed("ns"), sealed("cluster")
+				namespaceWide.Scope = "namespace-wide"
+				clusterWide.Scope = "cluster-wide"
+				iv.SealedSecrets = []schema.SealedSecretDefinition{namespaceWide, clusterWide}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				require.NoError(t, err)
+				namespaceWide := findResourceOrFail[*sealedsecrets.SealedSecret](t, r, "SealedSecret", "service--component--test--sealed--ns")
+				assert.Equal(t, map[string]string{sealedsecrets.NamespaceWideAnnotation: "true"}, namespaceWide.Annotations)
+				clusterWide := findResourceOrFail[*sealedsecrets.SealedSecret](t, r, "SealedSecret", "service--component--test--sealed--cluster")
+				assert.Equal(t, map[string]string{sealedsecrets.ClusterWideAnnotation: "true"}, clusterWide.Annotations)
+			},
+		},
+		"applies the template": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				secret := sealed("registry")
+				secret.Template = &schema.SealedSecretTemplate{
+					Type:        corev1.SecretTypeDockerConfigJson,
+					Labels:      map[string]string{"foo": "bar"},
+					Annotations: map[string]string{"baz": "qux"},
+				}
+				iv.SealedSecrets = []schema.SealedSecretDefinition{secret}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				require.NoError(t, err)
+				secret := findResourceOrFail[*sealedsecrets.SealedSecret](t, r, "SealedSecret", "service--component--test--sealed--registry")
+				assert.Equal(t, corev1.SecretTypeDockerConfigJson, secret.Spec.Template.Type)
+				assert.Equal(t, "bar", secret.Spec.Template.Labels["foo"])
+				assert.Equal(t, "service--component--test", secret.Spec.Template.Labels["app"])
+				assert.Equal(t, map[string]string{"baz": "qux"}, secret.Spec.Template.Annotations)
+			},
+		},
+		"fails on duplicate names across containers": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.SealedSecrets = []schema.SealedSecretDefinition{sealed("db")}
+				iv.Sidecars = map[string]schema.Container{
+					"proxy": {
+						Image:         schema.Image{Repository: "proxy", Tag: ptr.To("1")},
+						SealedSecrets: []schema.SealedSecretDefinition{sealed("db")},
+					},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				assert.ErrorContains(t, err, "duplicate sealed secret names in multiple containers: service--component--test--sealed--db")
+			},
+		},
+		"fails on names clashing with external secrets": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.SealedSecrets = []schema.SealedSecretDefinition{sealed("db")}
+				iv.ExternalSecrets = []schema.ExternalSecretDefinition{{
+					SecretStore: es.SecretStoreRef{Name: "sealed"},
+					Mapping:     map[string]schema.SecretMapping{"db": nil},
+				}}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, r []NamedResource, err error) {
+				assert.ErrorContains(t, err, "duplicate sealed secret names")
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("image_tag"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			require.NoError(t, err)
+			shouldCreate, createFn := CreateSealedSecrets(deploymentValues)
+			require.True(t, shouldCreate)
+			r, err := createFn(deploymentValues)
+			config.Asserts(t, deploymentValues, r, err)
+		})
+	}
+}
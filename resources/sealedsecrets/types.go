@@ -0,0 +1,68 @@
+package sealedsecrets
+
+// -----------------------
+// trimmed down copy of the `bitnami.com/v1alpha1` SealedSecret types from https://github.com/bitnami-labs/sealed-secrets/tree/main/pkg/apis/sealedsecrets/v1alpha1
+// same reasoning as the `postgresql` package - the upstream module pulls in the controller and its crypto/client dependencies
+// field names and JSON tags are unchanged
+// -----------------------
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var SchemeGroupVersion = schema.GroupVersion{Group: "bitnami.com", Version: "v1alpha1"}
+
+const (
+	// the ciphertext can be unsealed under any name in the sealed namespace
+	NamespaceWideAnnotation = "sealedsecrets.bitnami.com/namespace-wide"
+	// the ciphertext can be unsealed under any name in any namespace
+	ClusterWideAnnotation = "sealedsecrets.bitnami.com/cluster-wide"
+)
+
+// SealedSecret is the K8s representation of a "sealed Secret" - a regular k8s Secret that has been
+// sealed (encrypted) using the controller's key.
+type SealedSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+
+	Spec SealedSecretSpec `json:"spec"`
+}
+
+// SealedSecretSpec is the specification of a SealedSecret
+type SealedSecretSpec struct {
+	// Template defines the structure of the Secret that will be created from this sealed secret.
+	Template SecretTemplateSpec `json:"template,omitempty"`
+
+	// EncryptedData is a map of key => base64 encoded, sealed value
+	EncryptedData map[string]string `json:"encryptedData"`
+}
+
+// SecretTemplateSpec describes the structure a Secret should have when created from a template
+type SecretTemplateSpec struct {
+	// Standard object's metadata.
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Used to facilitate programmatic handling of secret data.
+	Type corev1.SecretType `json:"type,omitempty"`
+}
+
+// DeepCopyObject is only here so the type satisfies `runtime.Object`, same as in the `cilium` package
+func (in *SealedSecret) DeepCopyObject() runtime.Object {
+	if in == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(in)
+	if err != nil {
+		panic(err)
+	}
+	out := &SealedSecret{}
+	if err := json.Unmarshal(bytes, out); err != nil {
+		panic(err)
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+package resources
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func CreateSecrets(values DeploymentValues) (bool, ResourceCreator) {
+	return len(values.Secrets) > 0, func(values DeploymentValues) ([]NamedResource, error) {
+		resources := []NamedResource{}
+		for name, contents := range sortedMap(values.Secrets) {
+			data := map[string][]byte{}
+			for key, value := range contents {
+				data[key] = []byte(value)
+			}
+			secret := corev1.Secret{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: corev1.SchemeGroupVersion.Identifier(),
+					Kind:       "Secret",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      chartSecretName(name, values.Metadata),
+					Namespace: values.Metadata.Namespace,
+					Labels:    commonLabels(values.Metadata),
+				},
+				Type: corev1.SecretTypeOpaque,
+				Data: data,
+			}
+			u, err := toUnstructured(&secret)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategorySecrets, Key: name, Object: u[0]})
+		}
+		return resources, nil
+	}
+}
+
+// `kubeSecrets` can refer to the `secrets` by their key, those are swapped for the actual Secret name
+func resolveSecretReferences(values *DeploymentValues) {
+	resolve := func(container *Container) {
+		var resolved map[string]map[string]*string
+		for name, mapping := range container.KubeSecrets {
+			if _, ok := values.Secrets[name]; !ok {
+				continue
+			}
+			// the map is shared with the input, copy before changing it
+			if resolved == nil {
+				resolved = maps.Clone(container.KubeSecrets)
+			}
+			delete(resolved, name)
+			resolved[chartSecretName(name, values.Metadata)] = mapping
+		}
+		if resolved != nil {
+			container.KubeSecrets = resolved
+		}
+	}
+
+	forEachContainer(values, resolve)
+}
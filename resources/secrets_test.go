@@ -0,0 +1,111 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	"github.com/jinzhu/copier"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+)
+
+func TestSecrets(t *testing.T) {
+	type CaseConfig struct {
+		ValuesTransform func(*schema.InputValues)
+		Asserts         func(*testing.T, DeploymentValues, error)
+	}
+
+	cases := map[string]CaseConfig{
+		"renders Secrets": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Secrets = map[string]map[string]string{
+					"app-config": {"API_KEY": "value"},
+					"flags":      {"ENABLED": "true"},
+				}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				shouldCreate, createFn := CreateSecrets(dv)
+				require.True(t, shouldCreate)
+				r, err := createFn(dv)
+				require.NoError(t, err)
+				require.Len(t, r, 2)
+
+				secret := findResourceOrFail[*corev1.Secret](t, r, "Secret", "service--component--test-app-config")
+				assert.Equal(t, corev1.SecretTypeOpaque, secret.Type)
+				assert.Equal(t, map[string][]byte{"API_KEY": []byte("value")}, secret.Data)
+				findResourceOrFail[*corev1.Secret](t, r, "Secret", "service--component--test-flags")
+
+				outputs := BuildOutputs(r)
+				assert.Equal(t, "service--component--test-flags", outputs.Secrets["flags"].Name)
+			},
+		},
+		"resolves kubeSecrets references in all containers": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.Secrets = map[string]map[string]string{"app-config": {"API_KEY": "value"}}
+				iv.KubeSecrets = map[string]schema.SecretMapping{
+					"app-config": {"KEY": ptr.To("API_KEY")},
+					"existing":   nil,
+				}
+				iv.Cronjobs = []schema.Cronjob{{
+					Name:     "job",
+					Schedule: "* * * * *",
+					Container: schema.Container{
+						Image:       schema.Image{Repository: "job", Tag: ptr.To("1")},
+						KubeSecrets: map[string]schema.SecretMapping{"app-config": nil},
+					},
+				}}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[string]schema.SecretMapping{
+					"service--component--test-app-config": {"KEY": ptr.To("API_KEY")},
+					"existing":                            nil,
+				}, dv.Containers[0].KubeSecrets)
+				assert.Equal(t, map[string]schema.SecretMapping{
+					"service--component--test-app-config": nil,
+				}, dv.Cronjobs[0].Container.KubeSecrets)
+			},
+		},
+		"does not touch kubeSecrets without Secrets": {
+			ValuesTransform: func(iv *schema.InputValues) {
+				iv.KubeSecrets = map[string]schema.SecretMapping{"app-config": nil}
+			},
+			Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, map[string]schema.SecretMapping{"app-config": nil}, dv.Containers[0].KubeSecrets)
+				shouldCreate, _ := CreateSecrets(dv)
+				assert.False(t, shouldCreate)
+			},
+		},
+	}
+
+	base := schema.InputValues{
+		Metadata: schema.Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Container: schema.Container{
+			Image: schema.Image{
+				Repository: "image_repository",
+				Tag:        ptr.To("image_tag"),
+			},
+			Ports: []schema.Port{{Port: 8080}},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := schema.InputValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			config.ValuesTransform(&values)
+
+			deploymentValues, err := PrepareDeploymentValues(values)
+			config.Asserts(t, deploymentValues, err)
+		})
+	}
+}
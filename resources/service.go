@@ -2,8 +2,8 @@ package resources
 
 import (
 	"fmt"
+	"slices"
 
-	"dario.cat/mergo"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -32,16 +32,14 @@ func CreateService(values DeploymentValues) (bool, ResourceCreator) {
 				}(),
 			},
 			Spec: corev1.ServiceSpec{
-				Selector: map[string]string{
-					"app": serviceName(values.Metadata),
-				},
-				Type:  values.Service.Type,
-				Ports: getServicePorts(values),
+				Selector: podSelectorLabels(values),
+				Type:     values.Service.Type,
+				Ports:    getServicePorts(values),
 			},
 		}
 
 		if values.Service.RawSpec != nil {
-			if err := mergo.Merge(&service.Spec, *values.Service.RawSpec, mergo.WithOverride); err != nil {
+			if err := values.Explanation.merge(&service.Spec, *values.Service.RawSpec, "serviceConfig"); err != nil {
 				return nil, fmt.Errorf("merging raw serviceConfig spec: %v", err)
 			}
 		}
@@ -50,14 +48,100 @@ func CreateService(values DeploymentValues) (bool, ResourceCreator) {
 		if err != nil {
 			return nil, err
 		}
-		return []NamedResource{{Category: CategoryService, Object: u[0]}}, nil
+		resources := []NamedResource{{Category: CategoryService, Object: u[0]}}
+
+		for name, config := range sortedMap(values.Services) {
+			ports, err := getAdditionalServicePorts(values, config)
+			if err != nil {
+				return nil, fmt.Errorf("service %q: %v", name, err)
+			}
+			service := corev1.Service{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: corev1.SchemeGroupVersion.Identifier(),
+					Kind:       "Service",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:        additionalServiceName(name, values.Metadata),
+					Namespace:   values.Metadata.Namespace,
+					Annotations: config.Annotations,
+					Labels:      withCommonLabels(config.Labels, values.Metadata),
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: podSelectorLabels(values),
+					Type:     config.Type,
+					Ports:    ports,
+				},
+			}
+
+			if config.RawSpec != nil {
+				if err := values.Explanation.merge(&service.Spec, *config.RawSpec, fmt.Sprintf("services.%s.spec", name)); err != nil {
+					return nil, fmt.Errorf("merging raw spec of service %q: %v", name, err)
+				}
+			}
+
+			u, err := toUnstructured(&service)
+			if err != nil {
+				return nil, err
+			}
+			resources = append(resources, NamedResource{Category: CategoryService, Key: name, Object: u[0]})
+		}
+		return resources, nil
 	}
 }
 
+type containerServicePort struct {
+	corev1.ServicePort
+
+	Container string
+	// whether the port is part of the main Service
+	Exposed bool
+}
+
 func getServicePorts(values DeploymentValues) []corev1.ServicePort {
 	ports := []corev1.ServicePort{}
+	for _, port := range getContainerServicePorts(values.Containers) {
+		if port.Exposed {
+			ports = append(ports, port.ServicePort)
+		}
+	}
+	return ports
+}
+
+// getAdditionalServicePorts picks the ports selected by name or container - `expose: false` only keeps a port out of
+// the main Service, it can still be picked here. `nodePort` is only ever set on the main Service, so the same node
+// port isn't claimed twice
+func getAdditionalServicePorts(values DeploymentValues, config AdditionalService) ([]corev1.ServicePort, error) {
+	all := getContainerServicePorts(values.Containers)
+	for _, name := range config.Ports {
+		if !slices.ContainsFunc(all, func(p containerServicePort) bool { return p.Name == name }) {
+			return nil, fmt.Errorf("port %q not found", name)
+		}
+	}
+	for _, container := range config.Containers {
+		if !slices.ContainsFunc(values.Containers, func(c Container) bool { return c.Name == container }) {
+			return nil, fmt.Errorf("container %q not found", container)
+		}
+	}
+
+	ports := []corev1.ServicePort{}
+	for _, port := range all {
+		if slices.Contains(config.Ports, port.Name) || slices.Contains(config.Containers, port.Container) {
+			p := port.ServicePort
+			p.NodePort = 0
+			ports = append(ports, p)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("no ports selected")
+	}
+	return ports, nil
+}
 
-	for i, container := range values.Containers {
+// getContainerServicePorts lists every port of the containers, exposed or not, named the same way as on the Service
+func getContainerServicePorts(containers []Container) []containerServicePort {
+	ports := []containerServicePort{}
+
+	for i, container := range containers {
 		for j, port := range container.Ports {
 			p := corev1.ServicePort{
 				Protocol:   corev1.ProtocolTCP,
@@ -74,14 +158,16 @@ func getServicePorts(values DeploymentValues) []corev1.ServicePort {
 				// main container, first port => "main" port
 				p.Name = "main-port"
 			} else {
-				p.Name = fmt.Sprintf("other-port-%s-%d", container.Name, j)
+				p.Name = truncateName(fmt.Sprintf("other-port-%s-%d", container.Name, j), maxLabelNameLength)
 			}
 			if port.Name != nil {
 				p.Name = *port.Name
 			}
-			if port.Expose == nil || *port.Expose {
-				ports = append(ports, p)
-			}
+			ports = append(ports, containerServicePort{
+				ServicePort: p,
+				Container:   container.Name,
+				Exposed:     port.Expose == nil || *port.Expose,
+			})
 		}
 	}
 	return ports
@@ -1,12 +1,14 @@
 package resources
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/ProRocketeers/yoke-chart/schema"
 	"github.com/jinzhu/copier"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/ptr"
@@ -250,3 +252,149 @@ func TestService(t *testing.T) {
 		})
 	}
 }
+
+func TestAdditionalServices(t *testing.T) {
+	type CaseConfig struct {
+		Services map[string]AdditionalService
+		Asserts  func(*testing.T, []NamedResource, error)
+	}
+
+	cases := map[string]CaseConfig{
+		"selects ports by name": {
+			Services: map[string]AdditionalService{
+				"metrics": {ServiceConfig: ServiceConfig{Type: corev1.ServiceTypeClusterIP}, Ports: []string{"metrics"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				require.NoError(t, err)
+				require.Len(t, r, 2)
+				assert.Equal(t, CategoryService, r[1].Category)
+				assert.Equal(t, "metrics", r[1].Key)
+
+				s := fromUnstructuredOrPanic[*corev1.Service](r[1])
+				assert.Equal(t, "service--component--test-metrics", s.Name)
+				assert.Equal(t, map[string]string{"app": "service--component--test"}, s.Spec.Selector)
+				assert.Equal(t, []corev1.ServicePort{{
+					Name:       "metrics",
+					Protocol:   corev1.ProtocolTCP,
+					Port:       int32(9090),
+					TargetPort: intstr.FromInt(9090),
+				}}, s.Spec.Ports)
+			},
+		},
+		"selects all ports of a container": {
+			Services: map[string]AdditionalService{
+				"proxy": {ServiceConfig: ServiceConfig{Type: corev1.ServiceTypeClusterIP}, Containers: []string{"proxy"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				require.NoError(t, err)
+				s := fromUnstructuredOrPanic[*corev1.Service](r[1])
+				assert.Len(t, s.Spec.Ports, 2)
+				assert.Equal(t, "other-port-proxy-0", s.Spec.Ports[0].Name)
+				assert.Equal(t, "other-port-proxy-1", s.Spec.Ports[1].Name)
+			},
+		},
+		"can pick ports not exposed on the main Service": {
+			Services: map[string]AdditionalService{
+				"admin": {ServiceConfig: ServiceConfig{Type: corev1.ServiceTypeClusterIP}, Ports: []string{"admin"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				require.NoError(t, err)
+				main := fromUnstructuredOrPanic[*corev1.Service](r[0])
+				assert.False(t, slices.ContainsFunc(main.Spec.Ports, func(p corev1.ServicePort) bool { return p.Name == "admin" }))
+
+				s := fromUnstructuredOrPanic[*corev1.Service](r[1])
+				assert.Equal(t, "admin", s.Spec.Ports[0].Name)
+			},
+		},
+		"has its own type, annotations, labels and raw spec": {
+			Services: map[string]AdditionalService{
+				"public": {
+					ServiceConfig: ServiceConfig{
+						Type:        corev1.ServiceTypeLoadBalancer,
+						Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"},
+						Labels:      map[string]string{"custom": "label"},
+						RawSpec:     &corev1.ServiceSpec{ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal},
+					},
+					Ports: []string{"main-port"},
+				},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				require.NoError(t, err)
+				s := fromUnstructuredOrPanic[*corev1.Service](r[1])
+				assert.Equal(t, corev1.ServiceTypeLoadBalancer, s.Spec.Type)
+				assert.Equal(t, corev1.ServiceExternalTrafficPolicyLocal, s.Spec.ExternalTrafficPolicy)
+				assert.Equal(t, map[string]string{"service.beta.kubernetes.io/aws-load-balancer-type": "nlb"}, s.Annotations)
+				assert.Subset(t, s.Labels, map[string]string{"custom": "label", "app": "service--component--test"})
+				// node ports belong to the main Service only
+				assert.Zero(t, s.Spec.Ports[0].NodePort)
+			},
+		},
+		"fails on an unknown port": {
+			Services: map[string]AdditionalService{
+				"metrics": {Ports: []string{"nope"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				assert.ErrorContains(t, err, `port "nope" not found`)
+			},
+		},
+		"fails on an unknown container": {
+			Services: map[string]AdditionalService{
+				"metrics": {Containers: []string{"nope"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				assert.ErrorContains(t, err, `container "nope" not found`)
+			},
+		},
+		"fails when a container has no ports": {
+			Services: map[string]AdditionalService{
+				"metrics": {Containers: []string{"idle"}},
+			},
+			Asserts: func(t *testing.T, r []NamedResource, err error) {
+				assert.ErrorContains(t, err, "no ports selected")
+			},
+		},
+	}
+
+	base := DeploymentValues{
+		Metadata: Metadata{
+			Namespace:   "ns",
+			Service:     "service",
+			Component:   "component",
+			Environment: "test",
+		},
+		Service: ServiceConfig{Type: corev1.ServiceTypeNodePort},
+		Containers: []Container{
+			{
+				Name:  "main",
+				Image: Image{Repository: "image_repository", Tag: ptr.To("image_tag")},
+				Ports: []schema.Port{
+					{Port: 8080, NodePort: ptr.To(int32(31000))},
+					{Port: 9090, Name: ptr.To("metrics")},
+					{Port: 9091, Name: ptr.To("admin"), Expose: ptr.To(false)},
+				},
+			},
+			{
+				Name:  "proxy",
+				Image: Image{Repository: "proxy", Tag: ptr.To("tag")},
+				Ports: []schema.Port{{Port: 15000}, {Port: 15001}},
+			},
+			{
+				Name:  "idle",
+				Image: Image{Repository: "idle", Tag: ptr.To("tag")},
+			},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := DeploymentValues{}
+			copier.CopyWithOption(&values, &base, copier.Option{DeepCopy: true})
+			values.Services = config.Services
+
+			_, create := CreateService(values)
+			resources, err := create(values)
+
+			config.Asserts(t, resources, err)
+		})
+	}
+}
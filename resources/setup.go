@@ -11,37 +11,50 @@ import (
 )
 
 func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error) {
+	metadata, err := getMetadata(input)
+	if err != nil {
+		return DeploymentValues{}, err
+	}
+
 	values := DeploymentValues{
-		ReplicaCount:        1,
-		Autoscaling:         input.Autoscaling,
-		Strategy:            input.Strategy,
-		PodDisruptionBudget: input.PodDisruptionBudget,
-		Ingress:             input.Ingress,
-		HTTPRoutes:          resolveHttpRoutes(input),
-		NetworkPolicies:     input.NetworkPolicies,
-		Volumes:             input.Volumes,
-		ServiceAccount:      input.ServiceAccount,
-		Service:             ServiceConfig{Type: corev1.ServiceTypeClusterIP},
-		DB:                  input.DB,
-		Annotations:         input.Annotations,
-		PodAnnotations:      input.PodAnnotations,
-		Labels:              input.Labels,
-		PodLabels:           input.PodLabels,
-		SchedulingConfig:    input.SchedulingConfig,
-		PodSpec:             input.PodSpec,
-		ConfigMaps:          input.ConfigMaps,
-		ExtraManifests:      []unstructured.Unstructured{},
-		ServiceMonitor:      input.ServiceMonitor,
-		Kind:                "Deployment",
-		StatefulSetSpec:     input.StatefulSetSpec,
-		DeploymentSpec:      input.DeploymentSpec,
-
-		Metadata: Metadata{
-			Namespace:   input.Metadata.Namespace,
-			Service:     input.Metadata.Service,
-			Component:   input.Metadata.Component,
-			Environment: input.Metadata.Environment,
-		},
+		ReplicaCount:          1,
+		Autoscaling:           input.Autoscaling,
+		Strategy:              input.Strategy,
+		PodDisruptionBudget:   input.PodDisruptionBudget,
+		Ingress:               input.Ingress,
+		HTTPRoutes:            resolveHttpRoutes(input),
+		NetworkPolicies:       input.NetworkPolicies,
+		CiliumNetworkPolicies: input.CiliumNetworkPolicies,
+		Volumes:               input.Volumes,
+		ServiceAccount:        input.ServiceAccount,
+		Service:               ServiceConfig{Type: corev1.ServiceTypeClusterIP},
+		DB:                    input.DB,
+		Annotations:           input.Annotations,
+		PodAnnotations:        input.PodAnnotations,
+		Labels:                input.Labels,
+		PodLabels:             input.PodLabels,
+		GlobalLabels:          input.GlobalLabels,
+		GlobalAnnotations:     input.GlobalAnnotations,
+		SelectorLabels:        "app",
+		SchedulingConfig:      input.SchedulingConfig,
+		PodSpec:               input.PodSpec,
+		ConfigMaps:            input.ConfigMaps,
+		Secrets:               input.Secrets,
+		ExtraManifests:        []unstructured.Unstructured{},
+		Extensions:            input.Extensions,
+		ServiceMonitor:        input.ServiceMonitor,
+		PodMonitor:            input.PodMonitor,
+		PrometheusRules:       input.PrometheusRules,
+		SLOs:                  input.SLOs,
+		Dashboards:            input.Dashboards,
+		Istio:                 input.Istio,
+		Otel:                  input.Otel,
+		PushSecrets:           input.PushSecrets,
+		Kind:                  "Deployment",
+		StatefulSetSpec:       input.StatefulSetSpec,
+		DeploymentSpec:        input.DeploymentSpec,
+
+		Metadata: metadata,
 	}
 	if input.ReplicaCount != nil {
 		values.ReplicaCount = *input.ReplicaCount
@@ -51,6 +64,10 @@ func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error)
 		values.Kind = *input.Kind
 	}
 
+	if input.SelectorLabels != nil {
+		values.SelectorLabels = *input.SelectorLabels
+	}
+
 	if input.ServiceConfig != nil {
 		if input.ServiceConfig.Type != "" {
 			values.Service.Type = input.ServiceConfig.Type
@@ -61,6 +78,26 @@ func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error)
 		values.Service.RawSpec = &rawSpec
 	}
 
+	for name, service := range input.Services {
+		if values.Services == nil {
+			values.Services = map[string]AdditionalService{}
+		}
+		additional := AdditionalService{
+			ServiceConfig: ServiceConfig{
+				Type:        corev1.ServiceTypeClusterIP,
+				Annotations: service.Annotations,
+				Labels:      service.Labels,
+			},
+			Ports:      service.Ports,
+			Containers: service.Containers,
+		}
+		if service.Type != "" {
+			additional.Type = service.Type
+		}
+		additional.RawSpec = service.Spec
+		values.Services[name] = additional
+	}
+
 	if containers, err := getDeploymentContainers(input); err != nil {
 		return DeploymentValues{}, fmt.Errorf("error while preparing deployment containers: %v", err)
 	} else {
@@ -85,7 +122,7 @@ func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error)
 	}
 
 	if input.PreDeploymentJob != nil {
-		if preDeploymentJob, err := getPreDeploymentJob(input); err != nil {
+		if preDeploymentJob, err := getPreDeploymentJob(input, metadata); err != nil {
 			return DeploymentValues{}, fmt.Errorf("error while preparing pre-deployment job: %v", err)
 		} else {
 			values.PreDeploymentJob = &preDeploymentJob
@@ -93,13 +130,25 @@ func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error)
 	}
 
 	if len(input.Cronjobs) > 0 {
-		if cronjobs, err := getCronjobs(input); err != nil {
+		if cronjobs, err := getCronjobs(input, metadata); err != nil {
 			return DeploymentValues{}, fmt.Errorf("error while preparing cronjobs: %v", err)
 		} else {
 			values.Cronjobs = cronjobs
 		}
 	}
 
+	if len(values.Secrets) > 0 {
+		resolveSecretReferences(&values)
+	}
+
+	if values.Otel != nil {
+		applyOtelEnvs(&values)
+	}
+
+	if err := addExternalSecretVolumes(&values); err != nil {
+		return DeploymentValues{}, fmt.Errorf("error while preparing external secret volumes: %v", err)
+	}
+
 	for _, raw := range input.ExtraManifests {
 		values.ExtraManifests = append(values.ExtraManifests, unstructured.Unstructured{Object: raw})
 	}
@@ -107,6 +156,26 @@ func PrepareDeploymentValues(input schema.InputValues) (DeploymentValues, error)
 	return values, nil
 }
 
+func getMetadata(input schema.InputValues) (Metadata, error) {
+	metadata := Metadata{
+		Namespace:   input.Metadata.Namespace,
+		Service:     input.Metadata.Service,
+		Component:   input.Metadata.Component,
+		Environment: input.Metadata.Environment,
+	}
+	if input.Image.Tag != nil {
+		metadata.AppVersion = *input.Image.Tag
+	}
+	if input.NameTemplate != nil {
+		name, err := renderNameTemplate(*input.NameTemplate, input)
+		if err != nil {
+			return Metadata{}, fmt.Errorf("error while rendering nameTemplate: %v", err)
+		}
+		metadata.Name = name
+	}
+	return metadata, nil
+}
+
 func resolveHttpRoutes(input schema.InputValues) map[string]schema.HTTPRoute {
 	// validated to be mutually exclusive
 	if input.HTTPRoute != nil {
@@ -126,24 +195,25 @@ func getDeploymentContainers(input schema.InputValues) ([]Container, error) {
 		return []Container{}, fmt.Errorf("main container must have at least one port")
 	}
 	containers := []Container{
-		convertContainer(input.Container, input.MainContainerName, ptr.To("main")),
+		convertContainer(input.Container, "", input.MainContainerName, ptr.To("main")),
 	}
 	for sidecarName, sidecarInput := range sortedMap(input.Sidecars) {
 		if err := validateAndSetSideContainerImage(&sidecarInput.Image, &input.Image); err != nil {
 			return []Container{}, fmt.Errorf("error validating sidecar '%v': %v", sidecarName, err)
 		}
-		containers = append(containers, convertContainer(sidecarInput, ptr.To(sidecarName)))
+		containers = append(containers, convertContainer(sidecarInput, "sidecars."+sidecarName, ptr.To(sidecarName)))
 	}
 	return containers, nil
 }
 
 func getInitContainers(input schema.InputValues) ([]Container, error) {
 	containers := []Container{}
-	for _, initContainerInput := range input.InitContainers {
+	for i, initContainerInput := range input.InitContainers {
 		if err := validateAndSetSideContainerImage(&initContainerInput.Image, &input.Image); err != nil {
 			return []Container{}, fmt.Errorf("error validating init container '%v': %v", initContainerInput.Name, err)
 		}
-		containers = append(containers, convertContainer(initContainerInput.Container, ptr.To(initContainerInput.Name)))
+		valuesPath := fmt.Sprintf("initContainers[%d]", i)
+		containers = append(containers, convertContainer(initContainerInput.Container, valuesPath, ptr.To(initContainerInput.Name)))
 	}
 	return containers, nil
 }
@@ -160,19 +230,15 @@ func validateAndSetSideContainerImage(targetImage, mainImage *schema.Image) erro
 	return nil
 }
 
-func getPreDeploymentJob(input schema.InputValues) (PreDeploymentJob, error) {
+func getPreDeploymentJob(input schema.InputValues, metadata Metadata) (PreDeploymentJob, error) {
 	if err := validateAndSetSideContainerImage(&input.PreDeploymentJob.Image, &input.Image); err != nil {
 		return PreDeploymentJob{}, fmt.Errorf("error validating pre-deployment job main container: %v", err)
 	}
 
 	job := PreDeploymentJob{
-		Container: convertContainer(input.PreDeploymentJob.Container, input.PreDeploymentJob.MainContainerName, ptr.To("main")),
-		Metadata: Metadata{
-			Namespace:   input.Metadata.Namespace,
-			Service:     input.Metadata.Service,
-			Component:   input.Metadata.Component,
-			Environment: input.Metadata.Environment,
-		},
+		ValuesPath:       "preDeploymentJob",
+		Container:        convertContainer(input.PreDeploymentJob.Container, "preDeploymentJob", input.PreDeploymentJob.MainContainerName, ptr.To("main")),
+		Metadata:         metadata,
 		PodMonitor:       input.PreDeploymentJob.PodMonitor,
 		Volumes:          input.PreDeploymentJob.Volumes,
 		Annotations:      input.PreDeploymentJob.Annotations,
@@ -186,31 +252,29 @@ func getPreDeploymentJob(input schema.InputValues) (PreDeploymentJob, error) {
 
 	// init containers
 	initContainers := []Container{}
-	for _, initContainerInput := range input.PreDeploymentJob.InitContainers {
+	for i, initContainerInput := range input.PreDeploymentJob.InitContainers {
 		if err := validateAndSetSideContainerImage(&initContainerInput.Image, &input.Image); err != nil {
 			return PreDeploymentJob{}, fmt.Errorf("error validating pre-deployment job's init container '%v': %v", initContainerInput.Name, err)
 		}
-		initContainers = append(initContainers, convertContainer(initContainerInput.Container, ptr.To(initContainerInput.Name)))
+		valuesPath := fmt.Sprintf("preDeploymentJob.initContainers[%d]", i)
+		initContainers = append(initContainers, convertContainer(initContainerInput.Container, valuesPath, ptr.To(initContainerInput.Name)))
 	}
 	job.InitContainers = initContainers
 	return job, nil
 }
 
-func getCronjobs(input schema.InputValues) ([]Cronjob, error) {
+func getCronjobs(input schema.InputValues, metadata Metadata) ([]Cronjob, error) {
 	cronjobs := []Cronjob{}
 	for i := 0; i < len(input.Cronjobs); i++ {
 		if err := validateAndSetSideContainerImage(&input.Cronjobs[i].Image, &input.Image); err != nil {
 			return []Cronjob{}, fmt.Errorf("error validating cronjob '%v' main container: %v", input.Cronjobs[i].Name, err)
 		}
 
+		valuesPath := fmt.Sprintf("cronjobs[%d]", i)
 		cronjob := Cronjob{
-			Container: convertContainer(input.Cronjobs[i].Container, input.Cronjobs[i].MainContainerName, ptr.To("main")),
-			Metadata: Metadata{
-				Namespace:   input.Metadata.Namespace,
-				Service:     input.Metadata.Service,
-				Component:   input.Metadata.Component,
-				Environment: input.Metadata.Environment,
-			},
+			ValuesPath: valuesPath,
+			Container:  convertContainer(input.Cronjobs[i].Container, valuesPath, input.Cronjobs[i].MainContainerName, ptr.To("main")),
+			Metadata:   metadata,
 			Name:       input.Cronjobs[i].Name,
 			Schedule:   input.Cronjobs[i].Schedule,
 			Volumes:    input.Cronjobs[i].Volumes,
@@ -230,11 +294,12 @@ func getCronjobs(input schema.InputValues) ([]Cronjob, error) {
 
 		// init containers
 		initContainers := []Container{}
-		for _, initContainerInput := range input.Cronjobs[i].InitContainers {
+		for j, initContainerInput := range input.Cronjobs[i].InitContainers {
 			if err := validateAndSetSideContainerImage(&initContainerInput.Image, &input.Image); err != nil {
 				return []Cronjob{}, fmt.Errorf("error validating cronjob's '%v' init container '%v': %v", input.Cronjobs[i].Name, initContainerInput.Name, err)
 			}
-			initContainers = append(initContainers, convertContainer(initContainerInput.Container, ptr.To(initContainerInput.Name)))
+			initValuesPath := fmt.Sprintf("%s.initContainers[%d]", valuesPath, j)
+			initContainers = append(initContainers, convertContainer(initContainerInput.Container, initValuesPath, ptr.To(initContainerInput.Name)))
 		}
 		cronjob.InitContainers = initContainers
 		cronjobs = append(cronjobs, cronjob)
@@ -242,7 +307,7 @@ func getCronjobs(input schema.InputValues) ([]Cronjob, error) {
 	return cronjobs, nil
 }
 
-func convertContainer(container schema.Container, names ...*string) Container {
+func convertContainer(container schema.Container, valuesPath string, names ...*string) Container {
 	name := ""
 	// takes the first non-nil and non-empty name from the variadic names
 	// usage: container name overrides, but provide a default if the override is nil
@@ -256,7 +321,8 @@ func convertContainer(container schema.Container, names ...*string) Container {
 	}
 
 	return Container{
-		Name: name,
+		ValuesPath: valuesPath,
+		Name:       name,
 		Image: Image{
 			Repository:  container.Image.Repository,
 			Tag:         container.Image.Tag, // already should have proper image tag, respecting the inherit flag
@@ -270,6 +336,7 @@ func convertContainer(container schema.Container, names ...*string) Container {
 		EnvsRaw:         container.EnvsRaw,
 		KubeSecrets:     container.KubeSecrets,
 		ExternalSecrets: container.ExternalSecrets,
+		SealedSecrets:   container.SealedSecrets,
 		Resources:       container.Resources,
 		ReadinessProbe:  container.ReadinessProbe,
 		LivenessProbe:   container.LivenessProbe,
@@ -65,6 +65,26 @@ func TestSetup(t *testing.T) {
 				},
 			}
 		},
+		"additional services default to ClusterIP": func() CaseConfig {
+			return CaseConfig{
+				ValuesTransform: func(iv *schema.InputValues) {
+					iv.Services = map[string]schema.AdditionalService{
+						"internal": {Ports: []string{"main-port"}},
+						"public": {
+							Ports: []string{"main-port"},
+							Type:  corev1.ServiceTypeLoadBalancer,
+						},
+					}
+				},
+				Asserts: func(t *testing.T, dv DeploymentValues, err error) {
+					require.Nil(t, err)
+
+					assert.Equal(t, corev1.ServiceTypeClusterIP, dv.Services["internal"].Type)
+					assert.Equal(t, corev1.ServiceTypeLoadBalancer, dv.Services["public"].Type)
+					assert.Equal(t, []string{"main-port"}, dv.Services["public"].Ports)
+				},
+			}
+		},
 		"automatically overrides ServiceType if node port is specified on any port and service type is ClusterIP": func() CaseConfig {
 			return CaseConfig{
 				ValuesTransform: func(iv *schema.InputValues) {
@@ -0,0 +1,183 @@
+package resources
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// multi-window multi-burn-rate alerts as described in https://sre.google/workbook/alerting-on-slos/
+// the alert fires once `budgetConsumed` of the whole period's error budget was burnt within the `long` window
+// (`short` window only makes sure the burning is still happening, so the alert resolves quickly)
+type sloBurnRateWindow struct {
+	long           time.Duration
+	short          time.Duration
+	budgetConsumed float64
+}
+
+var (
+	sloRateWindows = []time.Duration{
+		5 * time.Minute, 30 * time.Minute, time.Hour, 2 * time.Hour, 6 * time.Hour, 24 * time.Hour, 72 * time.Hour,
+	}
+	sloPageWindows = []sloBurnRateWindow{
+		{long: time.Hour, short: 5 * time.Minute, budgetConsumed: 0.02},
+		{long: 6 * time.Hour, short: 30 * time.Minute, budgetConsumed: 0.05},
+	}
+	sloTicketWindows = []sloBurnRateWindow{
+		{long: 24 * time.Hour, short: 2 * time.Hour, budgetConsumed: 0.1},
+		{long: 72 * time.Hour, short: 6 * time.Hour, budgetConsumed: 0.1},
+	}
+)
+
+// sloRuleGroups renders a rule group per SLO (Sloth-style) - SLI error ratio recordings for all the alert windows and
+// the whole period, metadata recordings (objective, error budget, remaining budget) and the burn rate alerts
+func sloRuleGroups(values DeploymentValues) ([]monitoringv1.RuleGroup, error) {
+	groups := []monitoringv1.RuleGroup{}
+	for _, slo := range values.SLOs {
+		period := 30 * 24 * time.Hour
+		if slo.Window != nil {
+			window, err := model.ParseDuration(string(*slo.Window))
+			if err != nil {
+				return nil, fmt.Errorf("parsing SLO %q window: %v", slo.Name, err)
+			}
+			period = time.Duration(window)
+		}
+
+		labels := map[string]string{
+			"slo":         slo.Name,
+			"service":     values.Metadata.Service,
+			"component":   values.Metadata.Component,
+			"environment": values.Metadata.Environment,
+		}
+		// matches the series recorded for this SLO
+		sloSelector := fmt.Sprintf(`{slo=%q, service=%q, component=%q, environment=%q}`,
+			slo.Name, values.Metadata.Service, values.Metadata.Component, values.Metadata.Environment)
+		errorRatio := func(window time.Duration) string {
+			return fmt.Sprintf("slo:sli_error:ratio_rate%s%s", promDuration(window), sloSelector)
+		}
+
+		good, total := sliQueries(slo.SLI, values)
+		rules := []monitoringv1.Rule{}
+		for _, window := range sloRateWindows {
+			replacer := strings.NewReplacer("{{.window}}", promDuration(window))
+			rules = append(rules, monitoringv1.Rule{
+				Record: fmt.Sprintf("slo:sli_error:ratio_rate%s", promDuration(window)),
+				Expr:   intstr.FromString(fmt.Sprintf("1 - ((%s) / (%s))", replacer.Replace(good), replacer.Replace(total))),
+				Labels: labels,
+			})
+		}
+		if !slices.Contains(sloRateWindows, period) {
+			rules = append(rules, monitoringv1.Rule{
+				Record: fmt.Sprintf("slo:sli_error:ratio_rate%s", promDuration(period)),
+				Expr: intstr.FromString(fmt.Sprintf("sum_over_time(%s[%s]) / ignoring () count_over_time(%s[%s])",
+					errorRatio(5*time.Minute), promDuration(period), errorRatio(5*time.Minute), promDuration(period))),
+				Labels: labels,
+			})
+		}
+
+		objective := slo.Objective / 100
+		errorBudget := 1 - objective
+		rules = append(rules,
+			monitoringv1.Rule{
+				Record: "slo:objective:ratio",
+				Expr:   intstr.FromString(fmt.Sprintf("vector(%s)", promFloat(objective))),
+				Labels: labels,
+			},
+			monitoringv1.Rule{
+				Record: "slo:error_budget:ratio",
+				Expr:   intstr.FromString(fmt.Sprintf("vector(%s)", promFloat(errorBudget))),
+				Labels: labels,
+			},
+			monitoringv1.Rule{
+				Record: "slo:period_error_budget_remaining:ratio",
+				Expr:   intstr.FromString(fmt.Sprintf("1 - %s / %s", errorRatio(period), promFloat(errorBudget))),
+				Labels: labels,
+			},
+		)
+
+		if slo.Alerting == nil || !slo.Alerting.Disabled {
+			alerting := schema.SLOAlerting{PageSeverity: "critical", TicketSeverity: "warning"}
+			if slo.Alerting != nil {
+				alerting.Labels = slo.Alerting.Labels
+				alerting.Annotations = slo.Alerting.Annotations
+				if slo.Alerting.PageSeverity != "" {
+					alerting.PageSeverity = slo.Alerting.PageSeverity
+				}
+				if slo.Alerting.TicketSeverity != "" {
+					alerting.TicketSeverity = slo.Alerting.TicketSeverity
+				}
+			}
+
+			burnRateAlert := func(windows []sloBurnRateWindow, severity, speed string) monitoringv1.Rule {
+				conditions := []string{}
+				for _, w := range windows {
+					// burn rate at which `budgetConsumed` of the budget is gone after `long`
+					threshold := fmt.Sprintf("(%s * %s)", promFloat(w.budgetConsumed*period.Hours()/w.long.Hours()), promFloat(errorBudget))
+					conditions = append(conditions, fmt.Sprintf("(%s > %s and %s > %s)", errorRatio(w.long), threshold, errorRatio(w.short), threshold))
+				}
+
+				alertLabels := map[string]string{}
+				maps.Copy(alertLabels, labels)
+				alertLabels["severity"] = severity
+				maps.Copy(alertLabels, alerting.Labels)
+
+				annotations := map[string]string{
+					"summary":     fmt.Sprintf("SLO %s of %s is burning its error budget %s", slo.Name, serviceName(values.Metadata), speed),
+					"description": fmt.Sprintf("%s (objective %s%% over %s)", slo.Description, promFloat(slo.Objective), promDuration(period)),
+				}
+				maps.Copy(annotations, alerting.Annotations)
+
+				return monitoringv1.Rule{
+					Alert:       "SLOErrorBudgetBurn",
+					Expr:        intstr.FromString(strings.Join(conditions, " or ")),
+					Labels:      alertLabels,
+					Annotations: annotations,
+				}
+			}
+			rules = append(rules,
+				burnRateAlert(sloPageWindows, alerting.PageSeverity, "fast"),
+				burnRateAlert(sloTicketWindows, alerting.TicketSeverity, "slowly"),
+			)
+		}
+
+		groups = append(groups, monitoringv1.RuleGroup{
+			Name:  fmt.Sprintf("%s.slo.%s", serviceName(values.Metadata), slo.Name),
+			Rules: rules,
+		})
+	}
+	return groups, nil
+}
+
+// sliQueries returns the good/total event queries with `{{.window}}` still in place
+func sliQueries(sli schema.SLI, values DeploymentValues) (string, string) {
+	selector := fmt.Sprintf(`namespace=%q, service=%q`, values.Metadata.Namespace, serviceName(values.Metadata))
+
+	if sli.Latency != nil {
+		if sli.Latency.Selector != nil {
+			selector = *sli.Latency.Selector
+		}
+		good := fmt.Sprintf(`sum(rate(%s_bucket{%s, le=%q}[{{.window}}]))`, sli.Latency.Metric, selector, sli.Latency.Threshold)
+		total := fmt.Sprintf(`sum(rate(%s_count{%s}[{{.window}}]))`, sli.Latency.Metric, selector)
+		return good, total
+	}
+
+	replacer := strings.NewReplacer("{{.selector}}", selector)
+	return replacer.Replace(sli.Events.GoodQuery), replacer.Replace(sli.Events.TotalQuery)
+}
+
+func promDuration(d time.Duration) string {
+	return model.Duration(d).String()
+}
+
+// rounded to get rid of float noise, e.g. 1 - 0.999 = 0.0010000000000000009
+func promFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', 10, 64)
+}
@@ -0,0 +1,180 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/ProRocketeers/yoke-chart/schema"
+	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/utils/ptr"
+)
+
+func TestSLOs(t *testing.T) {
+	type CaseConfig struct {
+		SLO     schema.SLO
+		Asserts func(*testing.T, monitoringv1.RuleGroup)
+	}
+
+	findRecord := func(t *testing.T, group monitoringv1.RuleGroup, record string) monitoringv1.Rule {
+		for _, rule := range group.Rules {
+			if rule.Record == record {
+				return rule
+			}
+		}
+		t.Fatalf("recording rule %s not found", record)
+		return monitoringv1.Rule{}
+	}
+	alerts := func(group monitoringv1.RuleGroup) []monitoringv1.Rule {
+		rules := []monitoringv1.Rule{}
+		for _, rule := range group.Rules {
+			if rule.Alert != "" {
+				rules = append(rules, rule)
+			}
+		}
+		return rules
+	}
+
+	cases := map[string]CaseConfig{
+		"renders event based SLI with the Service selector": {
+			SLO: schema.SLO{
+				Name:      "availability",
+				Objective: 99.9,
+				SLI: schema.SLI{
+					Events: &schema.SLIEvents{
+						GoodQuery:  `sum(rate(http_requests_total{ {{.selector}}, code!~"5.."}[{{.window}}]))`,
+						TotalQuery: `sum(rate(http_requests_total{ {{.selector}} }[{{.window}}]))`,
+					},
+				},
+			},
+			Asserts: func(t *testing.T, group monitoringv1.RuleGroup) {
+				assert.Equal(t, "service--component--test.slo.availability", group.Name)
+
+				rate5m := findRecord(t, group, "slo:sli_error:ratio_rate5m")
+				assert.Equal(t,
+					`1 - ((sum(rate(http_requests_total{ namespace="ns", service="service--component--test", code!~"5.."}[5m]))) / (sum(rate(http_requests_total{ namespace="ns", service="service--component--test" }[5m]))))`,
+					rate5m.Expr.StrVal,
+				)
+				assert.Equal(t, map[string]string{
+					"slo":         "availability",
+					"service":     "service",
+					"component":   "component",
+					"environment": "test",
+				}, rate5m.Labels)
+				for _, window := range []string{"30m", "1h", "2h", "6h", "1d", "3d"} {
+					findRecord(t, group, "slo:sli_error:ratio_rate"+window)
+				}
+
+				period := findRecord(t, group, "slo:sli_error:ratio_rate30d")
+				assert.Contains(t, period.Expr.StrVal, `sum_over_time(slo:sli_error:ratio_rate5m{slo="availability", service="service", component="component", environment="test"}[30d])`)
+
+				assert.Equal(t, "vector(0.999)", findRecord(t, group, "slo:objective:ratio").Expr.StrVal)
+				assert.Equal(t, "vector(0.001)", findRecord(t, group, "slo:error_budget:ratio").Expr.StrVal)
+			},
+		},
+		"renders multi-window multi-burn-rate alerts": {
+			SLO: schema.SLO{
+				Name:      "availability",
+				Objective: 99.9,
+				SLI: schema.SLI{
+					Events: &schema.SLIEvents{GoodQuery: "good", TotalQuery: "total"},
+				},
+				Alerting: &schema.SLOAlerting{
+					TicketSeverity: "info",
+					Labels:         map[string]string{"team": "a"},
+				},
+			},
+			Asserts: func(t *testing.T, group monitoringv1.RuleGroup) {
+				rules := alerts(group)
+				require.Len(t, rules, 2)
+
+				page := rules[0]
+				assert.Equal(t, "SLOErrorBudgetBurn", page.Alert)
+				assert.Equal(t, "critical", page.Labels["severity"])
+				assert.Equal(t, "a", page.Labels["team"])
+				assert.Contains(t, page.Expr.StrVal, `slo:sli_error:ratio_rate1h{slo="availability", service="service", component="component", environment="test"} > (14.4 * 0.001)`)
+				assert.Contains(t, page.Expr.StrVal, `slo:sli_error:ratio_rate5m{slo="availability", service="service", component="component", environment="test"} > (14.4 * 0.001)`)
+				assert.Contains(t, page.Expr.StrVal, `ratio_rate6h{slo="availability", service="service", component="component", environment="test"} > (6 * 0.001)`)
+
+				ticket := rules[1]
+				assert.Equal(t, "info", ticket.Labels["severity"])
+				assert.Contains(t, ticket.Expr.StrVal, `ratio_rate1d{slo="availability", service="service", component="component", environment="test"} > (3 * 0.001)`)
+				assert.Contains(t, ticket.Expr.StrVal, `ratio_rate3d{slo="availability", service="service", component="component", environment="test"} > (1 * 0.001)`)
+			},
+		},
+		"scales burn rates with the window": {
+			SLO: schema.SLO{
+				Name:      "availability",
+				Objective: 99,
+				Window:    ptr.To(monitoringv1.Duration("7d")),
+				SLI: schema.SLI{
+					Events: &schema.SLIEvents{GoodQuery: "good", TotalQuery: "total"},
+				},
+			},
+			Asserts: func(t *testing.T, group monitoringv1.RuleGroup) {
+				findRecord(t, group, "slo:sli_error:ratio_rate1w")
+				// 2% of a week's budget in 1h
+				assert.Contains(t, alerts(group)[0].Expr.StrVal, "> (3.36 * 0.01)")
+			},
+		},
+		"renders latency SLI off a histogram": {
+			SLO: schema.SLO{
+				Name:      "latency",
+				Objective: 95,
+				SLI: schema.SLI{
+					Latency: &schema.SLILatency{
+						Metric:    "http_request_duration_seconds",
+						Threshold: "0.5",
+					},
+				},
+				Alerting: &schema.SLOAlerting{Disabled: true},
+			},
+			Asserts: func(t *testing.T, group monitoringv1.RuleGroup) {
+				assert.Equal(t,
+					`1 - ((sum(rate(http_request_duration_seconds_bucket{namespace="ns", service="service--component--test", le="0.5"}[1h]))) / (sum(rate(http_request_duration_seconds_count{namespace="ns", service="service--component--test"}[1h]))))`,
+					findRecord(t, group, "slo:sli_error:ratio_rate1h").Expr.StrVal,
+				)
+				assert.Empty(t, alerts(group))
+			},
+		},
+		"latency SLI can override the selector": {
+			SLO: schema.SLO{
+				Name:      "latency",
+				Objective: 95,
+				SLI: schema.SLI{
+					Latency: &schema.SLILatency{
+						Metric:    "http_request_duration_seconds",
+						Threshold: "0.5",
+						Selector:  ptr.To(`job="custom"`),
+					},
+				},
+			},
+			Asserts: func(t *testing.T, group monitoringv1.RuleGroup) {
+				assert.Contains(t, findRecord(t, group, "slo:sli_error:ratio_rate1h").Expr.StrVal, `http_request_duration_seconds_count{job="custom"}`)
+			},
+		},
+	}
+
+	for testName, config := range cases {
+		t.Run(testName, func(t *testing.T) {
+			values := DeploymentValues{
+				Metadata: Metadata{
+					Namespace:   "ns",
+					Service:     "service",
+					Component:   "component",
+					Environment: "test",
+				},
+				SLOs: []schema.SLO{config.SLO},
+			}
+
+			shouldCreate, create := CreatePrometheusRules(values)
+			require.True(t, shouldCreate)
+			resources, err := create(values)
+			require.NoError(t, err)
+
+			pr := fromUnstructuredOrPanic[*monitoringv1.PrometheusRule](resources[0])
+			require.Len(t, pr.Spec.Groups, 1)
+			config.Asserts(t, pr.Spec.Groups[0])
+		})
+	}
+}
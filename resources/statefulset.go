@@ -3,9 +3,7 @@ package resources
 import (
 	"fmt"
 	"maps"
-	"strings"
 
-	"dario.cat/mergo"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -15,8 +13,17 @@ import (
 func CreateStatefulSet(values DeploymentValues) (bool, ResourceCreator) {
 	return true, func(values DeploymentValues) ([]NamedResource, error) {
 		podAnnotations := map[string]string{}
+		maps.Copy(podAnnotations, istioPodAnnotations(values, false))
+		maps.Copy(podAnnotations, otelPodAnnotations(values))
 		maps.Copy(podAnnotations, values.PodAnnotations)
 
+		podLabels := map[string]string{}
+		maps.Copy(podLabels, istioPodLabels(values))
+		maps.Copy(podLabels, values.PodLabels)
+		if values.PodMonitor != nil && *values.PodMonitor.Enabled {
+			podLabels["prometheus-scrape"] = "true"
+		}
+
 		for _, container := range values.Containers {
 			podAnnotations["container-"+container.Name+"-image-tag"] = *container.Image.Tag
 		}
@@ -37,9 +44,7 @@ func CreateStatefulSet(values DeploymentValues) (bool, ResourceCreator) {
 				Labels:    commonLabels(values.Metadata),
 			},
 			Spec: corev1.ServiceSpec{
-				Selector: map[string]string{
-					"app": serviceName(values.Metadata),
-				},
+				Selector:  podSelectorLabels(values),
 				Type:      corev1.ServiceTypeClusterIP,
 				ClusterIP: corev1.ClusterIPNone,
 				Ports:     getServicePorts(values),
@@ -59,14 +64,12 @@ func CreateStatefulSet(values DeploymentValues) (bool, ResourceCreator) {
 			},
 			Spec: appsv1.StatefulSetSpec{
 				Selector: &metav1.LabelSelector{
-					MatchLabels: map[string]string{
-						"app": serviceName(values.Metadata),
-					},
+					MatchLabels: workloadSelectorLabels(values.Metadata),
 				},
 				Template: corev1.PodTemplateSpec{
 					ObjectMeta: metav1.ObjectMeta{
 						Annotations: podAnnotations,
-						Labels:      withCommonLabels(values.PodLabels, values.Metadata),
+						Labels:      withCommonLabels(podLabels, values.Metadata),
 					},
 					Spec: podSpec,
 				},
@@ -79,7 +82,7 @@ func CreateStatefulSet(values DeploymentValues) (bool, ResourceCreator) {
 		}
 
 		if values.StatefulSetSpec != nil {
-			if err := mergo.Merge(&statefulSet.Spec, *values.StatefulSetSpec, mergo.WithOverride); err != nil {
+			if err := values.Explanation.merge(&statefulSet.Spec, *values.StatefulSetSpec, "statefulSetSpec"); err != nil {
 				return nil, fmt.Errorf("merging raw statefulSet spec: %v", err)
 			}
 		}
@@ -94,8 +97,3 @@ func CreateStatefulSet(values DeploymentValues) (bool, ResourceCreator) {
 		}, nil
 	}
 }
-
-func headlessServiceName(metadata Metadata) string {
-	s := fmt.Sprintf("%s-headless", serviceName(metadata))
-	return strings.TrimSpace(s)
-}
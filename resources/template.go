@@ -96,7 +96,8 @@ func templateLeafValues(v any, ctx TemplateContext) (any, error) {
 	switch val := v.(type) {
 	case map[string]any:
 		out := make(map[string]any, len(val))
-		for k, item := range val {
+		// sorted, so the first failing key is always the same one
+		for k, item := range sortedMap(val) {
 			rendered, err := templateLeafValues(item, ctx)
 			if err != nil {
 				return nil, fmt.Errorf("%s: %w", k, err)
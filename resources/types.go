@@ -1,6 +1,8 @@
 package resources
 
 import (
+	"slices"
+
 	"github.com/ProRocketeers/yoke-chart/schema"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
@@ -17,36 +19,54 @@ type DeploymentValues struct {
 	Metadata   Metadata
 	Containers []Container
 
-	ReplicaCount        int
-	Autoscaling         *schema.HorizontalPodAutoscaler
-	Strategy            *appsv1.DeploymentStrategy
-	PodDisruptionBudget *policyv1.PodDisruptionBudgetSpec
-	InitContainers      []Container
-	Ingress             *schema.Ingress
-	HTTPRoutes          map[string]schema.HTTPRoute
-	NetworkPolicies     map[string]networkingv1.NetworkPolicySpec
-	Volumes             map[string]schema.Volume
-	PreDeploymentJob    *PreDeploymentJob
-	ServiceAccount      *schema.ServiceAccount
-	DB                  *schema.Database
-	Cronjobs            []Cronjob
-	ConfigMaps          map[string]map[string]string
-	ServiceMonitor      *schema.ServiceMonitor
-	Service             ServiceConfig
+	ReplicaCount          int
+	Autoscaling           *schema.HorizontalPodAutoscaler
+	Strategy              *appsv1.DeploymentStrategy
+	PodDisruptionBudget   *policyv1.PodDisruptionBudgetSpec
+	InitContainers        []Container
+	Ingress               *schema.Ingress
+	HTTPRoutes            map[string]schema.HTTPRoute
+	NetworkPolicies       map[string]networkingv1.NetworkPolicySpec
+	CiliumNetworkPolicies map[string]schema.CiliumNetworkPolicy
+	Volumes               map[string]schema.Volume
+	PreDeploymentJob      *PreDeploymentJob
+	ServiceAccount        *schema.ServiceAccount
+	DB                    *schema.Database
+	Cronjobs              []Cronjob
+	ConfigMaps            map[string]map[string]string
+	Secrets               map[string]map[string]string
+	ServiceMonitor        *schema.ServiceMonitor
+	PodMonitor            *schema.PodMonitor
+	PrometheusRules       *schema.PrometheusRules
+	SLOs                  []schema.SLO
+	Dashboards            map[string]schema.Dashboard
+	Istio                 *schema.Istio
+	Otel                  *schema.Otel
+	PushSecrets           map[string]schema.PushSecret
+	Service               ServiceConfig
+	Services              map[string]AdditionalService
 
 	Annotations    map[string]string
 	PodAnnotations map[string]string
 	Labels         map[string]string
 	PodLabels      map[string]string
 
+	GlobalLabels      map[string]string
+	GlobalAnnotations map[string]string
+	SelectorLabels    string
+
 	SchedulingConfig schema.SchedulingConfig
 	PodSpec          *corev1.PodSpec
 
 	ExtraManifests []unstructured.Unstructured
+	Extensions     map[string]map[string]interface{}
 
 	Kind            string
 	StatefulSetSpec *appsv1.StatefulSetSpec
 	DeploymentSpec  *appsv1.DeploymentSpec
+
+	// collects the fields set by the overrides (`podSpec`, `containerSpec`...), nil unless explaining the render
+	Explanation *Explanation
 }
 
 type Metadata struct {
@@ -54,6 +74,10 @@ type Metadata struct {
 	Service     string
 	Component   string
 	Environment string
+	// the main container's image tag, for the `app.kubernetes.io/version` label
+	AppVersion string
+	// rendered `nameTemplate`, replaces the default `{service}--{component}--{env}`
+	Name string
 }
 
 type ServiceConfig struct {
@@ -63,7 +87,18 @@ type ServiceConfig struct {
 	RawSpec     *corev1.ServiceSpec
 }
 
+type AdditionalService struct {
+	ServiceConfig
+
+	// port names to include, see `schema.AdditionalService`
+	Ports []string
+	// container names whose ports are all included
+	Containers []string
+}
+
 type Container struct {
+	// where the container is in the values, e.g. `sidecars.proxy` - empty for the main container, its fields are top-level
+	ValuesPath      string
 	Name            string
 	Image           Image
 	Args            []string
@@ -73,6 +108,7 @@ type Container struct {
 	EnvsRaw         []corev1.EnvVar
 	KubeSecrets     map[string]schema.SecretMapping
 	ExternalSecrets []schema.ExternalSecretDefinition
+	SealedSecrets   []schema.SealedSecretDefinition
 	Resources       *corev1.ResourceRequirements
 	ReadinessProbe  *corev1.Probe
 	LivenessProbe   *corev1.Probe
@@ -89,6 +125,7 @@ type Image struct {
 }
 
 type PreDeploymentJob struct {
+	ValuesPath       string
 	Metadata         Metadata
 	Container        Container
 	InitContainers   []Container
@@ -105,6 +142,8 @@ type PreDeploymentJob struct {
 }
 
 type Cronjob struct {
+	// `cronjobs[i]`
+	ValuesPath     string
 	Metadata       Metadata
 	Name           string
 	Schedule       string
@@ -128,6 +167,8 @@ type Cronjob struct {
 
 // common interface of the Pods from Deployment, Job and CronJobs
 type PodValues struct {
+	// empty for the main workload, whose pod fields are top-level
+	ValuesPath       string
 	ImagePullSecrets []corev1.LocalObjectReference
 	InitContainers   []Container
 	Metadata         Metadata
@@ -174,6 +215,7 @@ func (v *DeploymentValues) GetPodValues() PodValues {
 func (v *PreDeploymentJob) GetPodValues() PodValues {
 	// pod values for the pre deployment job
 	return PodValues{
+		ValuesPath:       v.ValuesPath,
 		ImagePullSecrets: getPullSecrets([]Container{v.Container}, v.InitContainers),
 		InitContainers:   v.InitContainers,
 		Metadata:         v.Metadata,
@@ -187,6 +229,7 @@ func (v *PreDeploymentJob) GetPodValues() PodValues {
 func (v *Cronjob) GetPodValues() PodValues {
 	// you guessed it..
 	return PodValues{
+		ValuesPath:       v.ValuesPath,
 		ImagePullSecrets: getPullSecrets([]Container{v.Container}, v.InitContainers),
 		InitContainers:   v.InitContainers,
 		Metadata:         v.Metadata,
@@ -204,30 +247,85 @@ func (v *Cronjob) GetPodValues() PodValues {
 type ResourceCategory string
 
 const (
-	CategoryWorkload                ResourceCategory = "Workload"
-	CategoryHeadlessService         ResourceCategory = "HeadlessService"
-	CategoryService                 ResourceCategory = "Service"
-	CategoryIngress                 ResourceCategory = "Ingress"
-	CategoryServiceAccount          ResourceCategory = "ServiceAccount"
-	CategoryPreDeploymentJob        ResourceCategory = "PreDeploymentJob"
-	CategoryHPA                     ResourceCategory = "HPA"
-	CategoryPDB                     ResourceCategory = "PDB"
-	CategoryDB                      ResourceCategory = "DB"
-	CategoryRole                    ResourceCategory = "Role"
-	CategoryRoleBinding             ResourceCategory = "RoleBinding"
-	CategoryClusterRole             ResourceCategory = "ClusterRole"
-	CategoryClusterRoleBinding      ResourceCategory = "ClusterRoleBinding"
-	CategoryServiceMonitor          ResourceCategory = "ServiceMonitor"
-	CategoryPreDeploymentPodMonitor ResourceCategory = "PreDeploymentPodMonitor"
-	CategoryHTTPRoutes              ResourceCategory = "HTTPRoutes"
-	CategoryNetworkPolicies         ResourceCategory = "NetworkPolicies"
-	CategoryConfigMaps              ResourceCategory = "ConfigMaps"
-	CategoryPVCs                    ResourceCategory = "PVCs"
-	CategoryCronjobs                ResourceCategory = "Cronjobs"
-	CategoryCronjobPodMonitors      ResourceCategory = "CronjobPodMonitors"
-	CategoryExternalSecrets         ResourceCategory = "ExternalSecrets"
+	CategoryWorkload                 ResourceCategory = "Workload"
+	CategoryHeadlessService          ResourceCategory = "HeadlessService"
+	CategoryService                  ResourceCategory = "Service"
+	CategoryIngress                  ResourceCategory = "Ingress"
+	CategoryServiceAccount           ResourceCategory = "ServiceAccount"
+	CategoryPreDeploymentJob         ResourceCategory = "PreDeploymentJob"
+	CategoryHPA                      ResourceCategory = "HPA"
+	CategoryPDB                      ResourceCategory = "PDB"
+	CategoryDB                       ResourceCategory = "DB"
+	CategoryRole                     ResourceCategory = "Role"
+	CategoryRoleBinding              ResourceCategory = "RoleBinding"
+	CategoryClusterRole              ResourceCategory = "ClusterRole"
+	CategoryClusterRoleBinding       ResourceCategory = "ClusterRoleBinding"
+	CategoryServiceMonitor           ResourceCategory = "ServiceMonitor"
+	CategoryPodMonitor               ResourceCategory = "PodMonitor"
+	CategoryPreDeploymentPodMonitor  ResourceCategory = "PreDeploymentPodMonitor"
+	CategoryPrometheusRule           ResourceCategory = "PrometheusRule"
+	CategoryVirtualService           ResourceCategory = "VirtualService"
+	CategoryDestinationRule          ResourceCategory = "DestinationRule"
+	CategoryPeerAuthentication       ResourceCategory = "PeerAuthentication"
+	CategoryAuthorizationPolicy      ResourceCategory = "AuthorizationPolicy"
+	CategoryHTTPRoutes               ResourceCategory = "HTTPRoutes"
+	CategoryNetworkPolicies          ResourceCategory = "NetworkPolicies"
+	CategoryCiliumNetworkPolicies    ResourceCategory = "CiliumNetworkPolicies"
+	CategoryConfigMaps               ResourceCategory = "ConfigMaps"
+	CategorySecrets                  ResourceCategory = "Secrets"
+	CategoryDashboards               ResourceCategory = "Dashboards"
+	CategoryPVCs                     ResourceCategory = "PVCs"
+	CategoryCronjobs                 ResourceCategory = "Cronjobs"
+	CategoryCronjobPodMonitors       ResourceCategory = "CronjobPodMonitors"
+	CategoryExternalSecrets          ResourceCategory = "ExternalSecrets"
+	CategoryExternalSecretGenerators ResourceCategory = "ExternalSecretGenerators"
+	CategoryPushSecrets              ResourceCategory = "PushSecrets"
+	CategorySealedSecrets            ResourceCategory = "SealedSecrets"
 )
 
+// builtinCategories are the categories of the chart's own resources, any other category belongs to an extension and its
+// resources are in `Outputs.Custom`
+var builtinCategories = []ResourceCategory{
+	CategoryWorkload,
+	CategoryHeadlessService,
+	CategoryService,
+	CategoryIngress,
+	CategoryServiceAccount,
+	CategoryPreDeploymentJob,
+	CategoryHPA,
+	CategoryPDB,
+	CategoryDB,
+	CategoryRole,
+	CategoryRoleBinding,
+	CategoryClusterRole,
+	CategoryClusterRoleBinding,
+	CategoryServiceMonitor,
+	CategoryPodMonitor,
+	CategoryPreDeploymentPodMonitor,
+	CategoryPrometheusRule,
+	CategoryVirtualService,
+	CategoryDestinationRule,
+	CategoryPeerAuthentication,
+	CategoryAuthorizationPolicy,
+	CategoryHTTPRoutes,
+	CategoryNetworkPolicies,
+	CategoryCiliumNetworkPolicies,
+	CategoryConfigMaps,
+	CategorySecrets,
+	CategoryDashboards,
+	CategoryPVCs,
+	CategoryCronjobs,
+	CategoryCronjobPodMonitors,
+	CategoryExternalSecrets,
+	CategoryExternalSecretGenerators,
+	CategoryPushSecrets,
+	CategorySealedSecrets,
+}
+
+func IsBuiltinCategory(category ResourceCategory) bool {
+	return slices.Contains(builtinCategories, category)
+}
+
 // NamedResource pairs a created object with its logical Category and, for map-keyed resources
 // (e.g. the HTTPRoute name), its Key. Key is empty for singular resources.
 type NamedResource struct {
@@ -14,43 +14,65 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 
 	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	gen "github.com/external-secrets/external-secrets/apis/generators/v1alpha1"
 	yaml "github.com/goccy/go-yaml"
 )
 
 type InputValues struct {
 	Metadata  `json:",inline"`
 	Container `json:",inline"`
+	// the yoke release the Flight is rendered for, set by the runtime - not part of the values
+	Release Release `json:"-"`
 
-	MainContainerName   *string                                   `json:"mainContainerName,omitempty"`
-	ReplicaCount        *int                                      `json:"replicaCount,omitempty"`
-	Autoscaling         *HorizontalPodAutoscaler                  `json:"autoscaling,omitempty"`
-	Strategy            *appsv1.DeploymentStrategy                `json:"strategy,omitempty"`
-	PodDisruptionBudget *policyv1.PodDisruptionBudgetSpec         `json:"podDisruptionBudget,omitempty"`
-	InitContainers      []InitContainer                           `json:"initContainers,omitempty" validate:"dive"`
-	Ingress             *Ingress                                  `json:"ingress,omitempty"`
-	HTTPRoute           *HTTPRoute                                `json:"httpRoute,omitempty"`
-	HTTPRoutes          map[string]HTTPRoute                      `json:"httpRoutes,omitempty" validate:"dive"`
-	NetworkPolicies     map[string]networkingv1.NetworkPolicySpec `json:"networkPolicies"`
-	Volumes             map[string]Volume                         `json:"volumes,omitempty" validate:"dive"`
-	Sidecars            map[string]Container                      `json:"sidecars,omitempty" validate:"dive"`
-	PreDeploymentJob    *PreDeploymentJob                         `json:"preDeploymentJob,omitempty"`
-	ServiceAccount      *ServiceAccount                           `json:"serviceAccount,omitempty"`
-	DB                  *Database                                 `json:"db,omitempty"`
-	Cronjobs            []Cronjob                                 `json:"cronjobs,omitempty" validate:"dive"`
-	ConfigMaps          map[string]map[string]string              `json:"configMaps"`
-	ServiceMonitor      *ServiceMonitor                           `json:"serviceMonitor"`
-
-	ServiceConfig *ServiceConfig `json:"serviceConfig,omitempty"`
+	NameTemplate *string `json:"nameTemplate,omitempty"`
+
+	MainContainerName     *string                                   `json:"mainContainerName,omitempty"`
+	ReplicaCount          *int                                      `json:"replicaCount,omitempty"`
+	Autoscaling           *HorizontalPodAutoscaler                  `json:"autoscaling,omitempty"`
+	Strategy              *appsv1.DeploymentStrategy                `json:"strategy,omitempty"`
+	PodDisruptionBudget   *policyv1.PodDisruptionBudgetSpec         `json:"podDisruptionBudget,omitempty"`
+	InitContainers        []InitContainer                           `json:"initContainers,omitempty" validate:"dive"`
+	Ingress               *Ingress                                  `json:"ingress,omitempty"`
+	HTTPRoute             *HTTPRoute                                `json:"httpRoute,omitempty"`
+	HTTPRoutes            map[string]HTTPRoute                      `json:"httpRoutes,omitempty" validate:"dive"`
+	NetworkPolicies       map[string]networkingv1.NetworkPolicySpec `json:"networkPolicies"`
+	CiliumNetworkPolicies map[string]CiliumNetworkPolicy            `json:"ciliumNetworkPolicies,omitempty" validate:"dive"`
+	Volumes               map[string]Volume                         `json:"volumes,omitempty" validate:"dive"`
+	Sidecars              map[string]Container                      `json:"sidecars,omitempty" validate:"dive"`
+	PreDeploymentJob      *PreDeploymentJob                         `json:"preDeploymentJob,omitempty"`
+	ServiceAccount        *ServiceAccount                           `json:"serviceAccount,omitempty"`
+	DB                    *Database                                 `json:"db,omitempty"`
+	Cronjobs              []Cronjob                                 `json:"cronjobs,omitempty" validate:"dive"`
+	ConfigMaps            map[string]map[string]string              `json:"configMaps"`
+	// usually SOPS-encrypted, rendered as Secrets which can be referenced by name in `kubeSecrets`
+	Secrets         map[string]map[string]string `json:"secrets,omitempty"`
+	ServiceMonitor  *ServiceMonitor              `json:"serviceMonitor"`
+	PodMonitor      *PodMonitor                  `json:"podMonitor,omitempty"`
+	PrometheusRules *PrometheusRules             `json:"prometheusRules,omitempty"`
+	SLOs            []SLO                        `json:"slos,omitempty" validate:"dive"`
+	Dashboards      map[string]Dashboard         `json:"dashboards,omitempty" validate:"dive"`
+	Istio           *Istio                       `json:"istio,omitempty"`
+	Otel            *Otel                        `json:"otel,omitempty"`
+	PushSecrets     map[string]PushSecret        `json:"pushSecrets,omitempty" validate:"dive"`
+
+	ServiceConfig *ServiceConfig               `json:"serviceConfig,omitempty"`
+	Services      map[string]AdditionalService `json:"services,omitempty" validate:"dive"`
 
 	Annotations    map[string]string `json:"annotations,omitempty"`
 	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
 	Labels         map[string]string `json:"labels,omitempty"`
 	PodLabels      map[string]string `json:"podLabels,omitempty"`
 
+	GlobalLabels      map[string]string `json:"globalLabels,omitempty"`
+	GlobalAnnotations map[string]string `json:"globalAnnotations,omitempty"`
+	SelectorLabels    *string           `json:"selectorLabels,omitempty" validate:"omitempty,oneof=app recommended"`
+
 	SchedulingConfig `json:",inline"`
 	PodSpec          *corev1.PodSpec `json:"podSpec,omitempty"`
 
 	ExtraManifests []map[string]interface{} `json:"extraManifests,omitempty"`
+	// OPTIONAL - free-form config of the Flight's extensions, keyed by the extension's category
+	Extensions map[string]map[string]interface{} `json:"extensions,omitempty"`
 
 	Kind            *string                 `json:"kind,omitempty"`
 	StatefulSetSpec *appsv1.StatefulSetSpec `json:"statefulSetSpec,omitempty"`
@@ -88,6 +110,11 @@ type Metadata struct {
 	Environment string `json:"environment" validate:"required"`
 }
 
+type Release struct {
+	Name      string
+	Namespace string
+}
+
 type Container struct {
 	Image Image `json:"image" validate:"required"`
 
@@ -98,6 +125,7 @@ type Container struct {
 	EnvsRaw         []corev1.EnvVar              `json:"envsRaw,omitempty" validate:"dive"`
 	KubeSecrets     map[string]SecretMapping     `json:"kubeSecrets,omitempty" validate:"dive"`
 	ExternalSecrets []ExternalSecretDefinition   `json:"externalSecrets,omitempty" validate:"dive"`
+	SealedSecrets   []SealedSecretDefinition     `json:"sealedSecrets,omitempty" validate:"dive"`
 	Resources       *corev1.ResourceRequirements `json:"resources,omitempty"`
 	ReadinessProbe  *corev1.Probe                `json:"readinessProbe,omitempty"`
 	LivenessProbe   *corev1.Probe                `json:"livenessProbe,omitempty"`
@@ -109,13 +137,70 @@ type Container struct {
 type SecretMapping = map[string]*string
 
 type ExternalSecretDefinition struct {
-	SecretStore     es.SecretStoreRef        `json:"secretStore" validate:"required"`
+	SecretStore     es.SecretStoreRef        `json:"secretStore" validate:"required_without=Generate,excluded_with=Generate"`
 	RefreshInterval *metav1.Duration         `json:"refreshInterval"`
-	Mapping         map[string]SecretMapping `json:"mapping" validate:"min=1,dive"`
+	Mapping         map[string]SecretMapping `json:"mapping" validate:"required_without=Generate,excluded_with=Generate,omitempty,min=1,dive"`
+	// OPTIONAL - instead of `secretStore`/`mapping`, the secret is generated by an ESO generator
+	Generate *ExternalSecretGenerator `json:"generate,omitempty"`
 
 	// OPTIONAL - defaults to `Owner`/`Delete` (today's behavior) when unset
 	CreationPolicy *es.ExternalSecretCreationPolicy `json:"creationPolicy,omitempty"`
 	DeletionPolicy *es.ExternalSecretDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// OPTIONAL - remote ref strategies for all the keys, default to `None`/`Default`/`None`
+	ExternalSecretStrategies `json:",inline"`
+	// OPTIONAL - strategies per mapped key (env name), override the ones above
+	Keys map[string]ExternalSecretStrategies `json:"keys,omitempty" validate:"dive"`
+	// OPTIONAL - shape of the created Secret(s) - type, extra templated keys, labels/annotations
+	Template *ExternalSecretTemplate `json:"template,omitempty"`
+
+	// OPTIONAL - how the container consumes the secret, `env` (default, `envFrom`), `volume` (files) or `none`
+	// (just the Secret, e.g. image pull secrets)
+	As     string                `json:"as,omitempty" validate:"omitempty,oneof=env volume none"`
+	Volume *ExternalSecretVolume `json:"volume,omitempty" validate:"required_if=As volume,excluded_unless=As volume"`
+}
+
+// exactly one of the generators has to be specified
+type ExternalSecretGenerator struct {
+	// part of the generated Secret's name, in place of the secret path
+	Name string `json:"name" validate:"required"`
+	// OPTIONAL - env name => generator output key (e.g. `password`; `username`/`password` for the registry tokens),
+	// all output keys under their own names when empty
+	Mapping SecretMapping `json:"mapping,omitempty"`
+
+	Password              *gen.PasswordSpec              `json:"password,omitempty"`
+	ECRAuthorizationToken *gen.ECRAuthorizationTokenSpec `json:"ecrAuthorizationToken,omitempty"`
+	GCRAccessToken        *gen.GCRAccessTokenSpec        `json:"gcrAccessToken,omitempty"`
+}
+
+type ExternalSecretStrategies struct {
+	DecodingStrategy   *es.ExternalSecretDecodingStrategy   `json:"decodingStrategy,omitempty" validate:"omitempty,oneof=Auto Base64 Base64URL None"`
+	ConversionStrategy *es.ExternalSecretConversionStrategy `json:"conversionStrategy,omitempty" validate:"omitempty,oneof=Default Unicode"`
+	MetadataPolicy     *es.ExternalSecretMetadataPolicy     `json:"metadataPolicy,omitempty" validate:"omitempty,oneof=None Fetch"`
+}
+
+type ExternalSecretTemplate struct {
+	// OPTIONAL - defaults to `Opaque`, e.g. `kubernetes.io/dockerconfigjson` or `kubernetes.io/tls`
+	Type corev1.SecretType `json:"type,omitempty"`
+	// OPTIONAL - extra Secret keys, values are ESO (engine v2) templates. Mapped keys are available as
+	// `{{ .<lower-cased env name> }}`, whole fetched secrets under their remote key names
+	Data        map[string]string `json:"data,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+}
+
+// secret mounted as files into the owning container, the volume itself is generated
+type ExternalSecretVolume struct {
+	MountPath   string `json:"mountPath" validate:"required"`
+	DefaultMode *int32 `json:"defaultMode,omitempty"`
+	// OPTIONAL - secret key => file, only the listed keys are mounted. All keys (file name = key) when empty
+	Files map[string]ExternalSecretFile `json:"files,omitempty" validate:"dive"`
+}
+
+type ExternalSecretFile struct {
+	// OPTIONAL - file name relative to `mountPath`, defaults to the key
+	Path string `json:"path,omitempty"`
+	Mode *int32 `json:"mode,omitempty"`
 }
 
 type InitContainer struct {
@@ -134,6 +219,24 @@ type ServiceConfig struct {
 	corev1.ServiceSpec `json:",inline"`
 }
 
+// an extra Service next to the main one, exposing only the selected ports (e.g. an internal metrics/admin
+// Service, or a LoadBalancer for a single port)
+type AdditionalService struct {
+	// port names - either the `name` of the port, or the generated one (`main-port`, `other-port-{container}-{index}`)
+	Ports []string `json:"ports,omitempty" validate:"required_without=Containers"`
+	// container names (main container or sidecars) - all of their ports are included
+	Containers []string `json:"containers,omitempty" validate:"required_without=Ports"`
+
+	// OPTIONAL - defaults to ClusterIP
+	Type        corev1.ServiceType `json:"type,omitempty" validate:"omitempty,oneof=ClusterIP NodePort LoadBalancer"`
+	Annotations map[string]string  `json:"annotations,omitempty"`
+	Labels      map[string]string  `json:"labels,omitempty"`
+
+	// OPTIONAL - escape hatch, same merge semantics as `serviceConfig`, but not inlined (its `ports` would clash
+	// with the port selection above)
+	Spec *corev1.ServiceSpec `json:"spec,omitempty"`
+}
+
 func init() {
 	// both `intstr.IntOrString` and `resource.Quantity` are fields that can have either int or string with specific formats
 	// in k8s YAML parser, they have their own YAML parsers so we have to somewhat duplicate that here as well
@@ -0,0 +1,51 @@
+package schema
+
+import "github.com/ProRocketeers/yoke-chart/resources/istio"
+
+type Istio struct {
+	Injection           *IstioInjection           `json:"injection,omitempty"`
+	VirtualService      *IstioVirtualService      `json:"virtualService,omitempty"`
+	DestinationRule     *IstioDestinationRule     `json:"destinationRule,omitempty"`
+	PeerAuthentication  *IstioPeerAuthentication  `json:"peerAuthentication,omitempty"`
+	AuthorizationPolicy *IstioAuthorizationPolicy `json:"authorizationPolicy,omitempty"`
+	// OPTIONAL - the cluster's DNS domain the chart's Service host is built with, defaults to `cluster.local`
+	ClusterDomain string `json:"clusterDomain,omitempty" validate:"omitempty,hostname_rfc1123"`
+}
+
+type IstioInjection struct {
+	Enabled *bool `json:"enabled" validate:"required"`
+	// OPTIONAL - extra sidecar annotations (e.g. `sidecar.istio.io/proxyCPU`), set on every Pod template
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// basically VirtualServiceSpec, but `hosts` default to the chart's Service and route destinations
+// without a `host` point to the chart's Service as well
+type IstioVirtualService struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+
+	Hosts    []string          `json:"hosts,omitempty"`
+	Gateways []string          `json:"gateways,omitempty"`
+	HTTP     []istio.HTTPRoute `json:"http" validate:"required,min=1"`
+}
+
+// basically DestinationRuleSpec without the `host` field, that one is always the chart's Service
+type IstioDestinationRule struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+
+	TrafficPolicy *istio.TrafficPolicy `json:"trafficPolicy,omitempty"`
+	Subsets       []istio.Subset       `json:"subsets,omitempty" validate:"dive"`
+}
+
+// basically PeerAuthenticationSpec without the `selector` field, that one is always bound to the chart's Pods
+type IstioPeerAuthentication struct {
+	Mtls          *istio.MutualTLS           `json:"mtls,omitempty"`
+	PortLevelMtls map[string]istio.MutualTLS `json:"portLevelMtls,omitempty" validate:"dive"`
+}
+
+// basically AuthorizationPolicySpec without the `selector` field, that one is always bound to the chart's Pods
+type IstioAuthorizationPolicy struct {
+	Action string       `json:"action,omitempty" validate:"omitempty,oneof=ALLOW DENY AUDIT CUSTOM"`
+	Rules  []istio.Rule `json:"rules,omitempty" validate:"dive"`
+}
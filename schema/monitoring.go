@@ -3,11 +3,79 @@ package schema
 import "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
 
 type ServiceMonitor struct {
-	Enabled   *bool         `json:"enabled" validate:"required"`
-	Endpoints []v1.Endpoint `json:"endpoints" validate:"required,min=1"`
+	Enabled *bool `json:"enabled" validate:"required"`
+	// shorthand for `endpoints` - names of ports exposed on the main Service (the port's `name`, or the generated
+	// `main-port`/`other-port-{container}-{index}`, sidecar ports included), each becomes an endpoint with defaults
+	Ports     []string      `json:"ports,omitempty" validate:"required_without=Endpoints"`
+	Endpoints []v1.Endpoint `json:"endpoints,omitempty" validate:"required_without=Ports"`
 }
 
 type PodMonitor struct {
-	Enabled   *bool                   `json:"enabled" validate:"required"`
-	Endpoints []v1.PodMetricsEndpoint `json:"endpoints" validate:"required,min=1"`
+	Enabled *bool `json:"enabled" validate:"required"`
+	// shorthand for `endpoints` - names of the Pod's container ports (same naming as for the Service), each becomes
+	// an endpoint scraping the container port directly, so the port doesn't have to be exposed on any Service
+	Ports     []string                `json:"ports,omitempty" validate:"required_without=Endpoints"`
+	Endpoints []v1.PodMetricsEndpoint `json:"endpoints,omitempty" validate:"required_without=Ports"`
+}
+
+type PrometheusRules struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+
+	// OPTIONAL - raw rule groups, rendered as-is
+	Groups []v1.RuleGroup `json:"groups,omitempty"`
+	// OPTIONAL - built-in alerts scoped to the chart's objects, each one is enabled just by being specified
+	Alerts *BuiltinAlerts `json:"alerts,omitempty"`
+}
+
+type BuiltinAlerts struct {
+	PodCrashLooping        *BuiltinAlert             `json:"podCrashLooping,omitempty"`
+	ReplicasUnavailable    *BuiltinAlert             `json:"replicasUnavailable,omitempty"`
+	HPAMaxedOut            *BuiltinAlert             `json:"hpaMaxedOut,omitempty"`
+	CronjobFailed          *BuiltinAlert             `json:"cronjobFailed,omitempty"`
+	CronjobNotScheduled    *CronjobNotScheduledAlert `json:"cronjobNotScheduled,omitempty"`
+	PreDeploymentJobFailed *BuiltinAlert             `json:"preDeploymentJobFailed,omitempty"`
+	PVCUsage               *PVCUsageAlert            `json:"pvcUsage,omitempty"`
+}
+
+type BuiltinAlert struct {
+	// OPTIONAL - how long the condition has to hold before firing, every alert has its own default
+	For *v1.Duration `json:"for,omitempty"`
+	// OPTIONAL - `severity` label, defaults to `warning`
+	Severity    string            `json:"severity,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type CronjobNotScheduledAlert struct {
+	BuiltinAlert `json:",inline"`
+
+	// how long since the last schedule is still fine, e.g. `25h` for a daily job
+	Within v1.Duration `json:"within" validate:"required"`
+}
+
+type PVCUsageAlert struct {
+	BuiltinAlert `json:",inline"`
+
+	// OPTIONAL - used space in percent, defaults to 80
+	Threshold *int `json:"threshold,omitempty" validate:"omitempty,min=1,max=100"`
+}
+
+// Grafana dashboard, provisioned through the Grafana sidecar as a labelled ConfigMap
+type Dashboard struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	// OPTIONAL - Grafana folder, set as the `grafana_folder` annotation (sidecar's `folderAnnotation`)
+	Folder string `json:"folder,omitempty"`
+
+	// exactly one of `builtin`/`json`
+	Builtin *BuiltinDashboard `json:"builtin,omitempty" validate:"required_without=JSON,excluded_with=JSON"`
+	// dashboard model JSON, e.g. exported from Grafana
+	JSON string `json:"json,omitempty" validate:"omitempty,json"`
+}
+
+type BuiltinDashboard struct {
+	// OPTIONAL - request counter for the request rate panel, defaults to `http_requests_total`
+	// the panel is only rendered with `serviceMonitor` enabled
+	RequestsMetric string `json:"requestsMetric,omitempty"`
 }
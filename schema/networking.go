@@ -1,6 +1,7 @@
 package schema
 
 import (
+	"github.com/ProRocketeers/yoke-chart/resources/cilium"
 	networkingv1 "k8s.io/api/networking/v1"
 	gatewayv1 "sigs.k8s.io/gateway-api/apis/v1"
 )
@@ -19,3 +20,13 @@ type HTTPRoute struct {
 
 	gatewayv1.HTTPRouteSpec `json:",inline"`
 }
+
+// basically Cilium's policy `Rule` without the `endpointSelector` field, that one is always bound to the chart's Pods
+type CiliumNetworkPolicy struct {
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+
+	Description string               `json:"description,omitempty"`
+	Ingress     []cilium.IngressRule `json:"ingress,omitempty" validate:"dive"`
+	Egress      []cilium.EgressRule  `json:"egress,omitempty" validate:"dive"`
+}
@@ -0,0 +1,218 @@
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/utils/ptr"
+)
+
+// fields required by the validator, which don't have to be set in the custom resource
+var optionalInCustomResource = map[string]bool{
+	// defaults to the release's namespace, which is the custom resource's namespace
+	"Metadata.Namespace": true,
+}
+
+// OpenAPISchema derives a structural OpenAPI v3 schema of the values from `InputValues`, to be used in a CRD.
+// Kubernetes types (raw specs and overrides) aren't described field by field, they are passed through as they are,
+// the Flight validates them the same way as any other values
+func OpenAPISchema() apiextensionsv1.JSONSchemaProps {
+	return typeSchema(reflect.TypeFor[InputValues]())
+}
+
+var (
+	intOrStringTypes = []reflect.Type{reflect.TypeFor[intstr.IntOrString](), reflect.TypeFor[resource.Quantity]()}
+	jsonMarshaler    = reflect.TypeFor[json.Marshaler]()
+	textMarshaler    = reflect.TypeFor[encoding.TextMarshaler]()
+	schemaPkgPath    = reflect.TypeFor[InputValues]().PkgPath()
+)
+
+func typeSchema(t reflect.Type) apiextensionsv1.JSONSchemaProps {
+	s := nonNullableTypeSchema(t)
+	// `~` in YAML is a valid value of these, e.g. `kubeSecrets` without a mapping
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		s.Nullable = true
+	}
+	return s
+}
+
+func nonNullableTypeSchema(t reflect.Type) apiextensionsv1.JSONSchemaProps {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case slices.Contains(intOrStringTypes, t):
+		return apiextensionsv1.JSONSchemaProps{XIntOrString: true}
+	case t.PkgPath() != schemaPkgPath && t.PkgPath() != "" && marshalsItself(t):
+		// durations and the like
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	case t == reflect.TypeFor[Volume]():
+		return volumeSchema()
+	case t == reflect.TypeFor[VolumeMountList]():
+		// a single mount is accepted too, but structural schemas can't express that
+		return apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: ptr.To(typeSchema(reflect.TypeFor[VolumeMount]()))},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return apiextensionsv1.JSONSchemaProps{Type: "string"}
+	case reflect.Bool:
+		return apiextensionsv1.JSONSchemaProps{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int64"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return apiextensionsv1.JSONSchemaProps{Type: "integer", Format: "int32"}
+	case reflect.Float32, reflect.Float64:
+		return apiextensionsv1.JSONSchemaProps{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return apiextensionsv1.JSONSchemaProps{
+			Type:  "array",
+			Items: &apiextensionsv1.JSONSchemaPropsOrArray{Schema: ptr.To(typeSchema(t.Elem()))},
+		}
+	case reflect.Map:
+		return apiextensionsv1.JSONSchemaProps{
+			Type:                 "object",
+			AdditionalProperties: &apiextensionsv1.JSONSchemaPropsOrBool{Allows: true, Schema: ptr.To(typeSchema(t.Elem()))},
+		}
+	case reflect.Struct:
+		if t.PkgPath() != schemaPkgPath {
+			return preserveUnknownFields()
+		}
+		return structSchema(t)
+	default:
+		// `interface{}` - arbitrary YAML
+		return apiextensionsv1.JSONSchemaProps{XPreserveUnknownFields: ptr.To(true)}
+	}
+}
+
+func structSchema(t reflect.Type) apiextensionsv1.JSONSchemaProps {
+	s := apiextensionsv1.JSONSchemaProps{Type: "object", Properties: map[string]apiextensionsv1.JSONSchemaProps{}}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, inline := jsonName(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if inline {
+			embedded := typeSchema(field.Type)
+			if embedded.XPreserveUnknownFields != nil {
+				// e.g. an inlined Kubernetes spec, its fields are unknown
+				s.XPreserveUnknownFields = ptr.To(true)
+			}
+			for propName, prop := range embedded.Properties {
+				s.Properties[propName] = prop
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+
+		prop := typeSchema(field.Type)
+		rules := strings.Split(field.Tag.Get("validate"), ",")
+		for _, rule := range rules {
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok && prop.Type == "string" {
+				for _, value := range strings.Fields(values) {
+					prop.Enum = append(prop.Enum, apiextensionsv1.JSON{Raw: []byte(fmt.Sprintf("%q", value))})
+				}
+			}
+		}
+		if slices.Contains(rules, "required") && !optionalInCustomResource[t.Name()+"."+field.Name] {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = prop
+	}
+	slices.Sort(s.Required)
+	return s
+}
+
+// volumes are a discriminated union by `type` - a structural schema can't have different properties per variant, so
+// it has the properties of all of them, and each variant's required fields are checked by its own `anyOf` branch
+func volumeSchema() apiextensionsv1.JSONSchemaProps {
+	s := structSchema(reflect.TypeFor[Volume]())
+	s.Properties["type"] = withEnum(s.Properties["type"],
+		VolumeTypeStandardTmpfs, VolumeTypeStandardLocal, VolumeTypeRaw, VolumeTypePersistent, VolumeTypeSecret, VolumeTypeConfigMap,
+	)
+
+	branch := func(volumeType VolumeType, existing *bool, variants ...any) apiextensionsv1.JSONSchemaProps {
+		b := apiextensionsv1.JSONSchemaProps{
+			Properties: map[string]apiextensionsv1.JSONSchemaProps{"type": withEnum(apiextensionsv1.JSONSchemaProps{}, volumeType)},
+		}
+		if existing != nil {
+			b.Properties["existing"] = apiextensionsv1.JSONSchemaProps{Enum: []apiextensionsv1.JSON{{Raw: []byte(fmt.Sprint(*existing))}}}
+		}
+		for _, variant := range variants {
+			props := structSchema(reflect.TypeOf(variant))
+			for name, prop := range props.Properties {
+				s.Properties[name] = prop
+			}
+			b.Required = append(b.Required, props.Required...)
+		}
+		return b
+	}
+
+	configMapName := branch(VolumeTypeConfigMap, nil, ConfigMapVolume{})
+	configMapName.Required = []string{"configMapName"}
+	configMapName.Not = &apiextensionsv1.JSONSchemaProps{Required: []string{"configMap"}}
+	configMap := branch(VolumeTypeConfigMap, nil)
+	configMap.Required = []string{"configMap"}
+	configMap.Not = &apiextensionsv1.JSONSchemaProps{Required: []string{"configMapName"}}
+
+	s.AnyOf = []apiextensionsv1.JSONSchemaProps{
+		branch(VolumeTypeStandardTmpfs, nil, StandardVolume{}),
+		branch(VolumeTypeStandardLocal, nil, StandardVolume{}),
+		branch(VolumeTypeRaw, nil, RawVolume{}),
+		branch(VolumeTypePersistent, ptr.To(true), PersistentVolume{}, PersistentVolumeExisting{}),
+		branch(VolumeTypePersistent, ptr.To(false), PersistentVolume{}, PersistentVolumeNew{}),
+		branch(VolumeTypeSecret, nil, SecretVolume{}),
+		configMapName,
+		configMap,
+	}
+	return s
+}
+
+func withEnum[T ~string](s apiextensionsv1.JSONSchemaProps, values ...T) apiextensionsv1.JSONSchemaProps {
+	s.Enum = nil
+	for _, value := range values {
+		s.Enum = append(s.Enum, apiextensionsv1.JSON{Raw: []byte(fmt.Sprintf("%q", value))})
+	}
+	return s
+}
+
+func preserveUnknownFields() apiextensionsv1.JSONSchemaProps {
+	return apiextensionsv1.JSONSchemaProps{Type: "object", XPreserveUnknownFields: ptr.To(true)}
+}
+
+// returns the JSON name of the field, and whether it's inlined into the parent
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		if field.Anonymous {
+			return "", true
+		}
+		return strings.ToLower(field.Name), false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "" && (field.Anonymous || slices.Contains(strings.Split(options, ","), "inline")) {
+		return "", true
+	}
+	if name == "" {
+		return strings.ToLower(field.Name), false
+	}
+	return name, false
+}
+
+func marshalsItself(t reflect.Type) bool {
+	p := reflect.PointerTo(t)
+	return t.Implements(jsonMarshaler) || p.Implements(jsonMarshaler) || t.Implements(textMarshaler) || p.Implements(textMarshaler)
+}
@@ -0,0 +1,17 @@
+package schema
+
+type Otel struct {
+	// OPTIONAL - `OTEL_SERVICE_NAME`, defaults to `{service}-{component}`
+	ServiceName string `json:"serviceName,omitempty"`
+	// OPTIONAL - `OTEL_EXPORTER_OTLP_ENDPOINT`, e.g. `http://otel-collector.monitoring:4317`
+	Endpoint string `json:"endpoint,omitempty" validate:"omitempty,url"`
+	// OPTIONAL - `OTEL_EXPORTER_OTLP_PROTOCOL`
+	Protocol string `json:"protocol,omitempty" validate:"omitempty,oneof=grpc http/protobuf http/json"`
+	// OPTIONAL - extra `OTEL_RESOURCE_ATTRIBUTES`, added to the ones derived from `metadata`
+	ResourceAttributes map[string]string `json:"resourceAttributes,omitempty"`
+	// OPTIONAL - OpenTelemetry Operator auto-instrumentation of the main workload, container name => language
+	Instrumentation map[string]string `json:"instrumentation,omitempty" validate:"dive,oneof=java nodejs python dotnet go apache-httpd nginx"`
+	// OPTIONAL - value of the `inject-{language}` annotations - `"true"` picks the Instrumentation of the namespace,
+	// `{name}` or `{namespace}/{name}` a specific one
+	InstrumentationRef string `json:"instrumentationRef,omitempty"`
+}
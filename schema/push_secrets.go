@@ -0,0 +1,29 @@
+package schema
+
+import (
+	es "github.com/external-secrets/external-secrets/apis/externalsecrets/v1"
+	esv1alpha1 "github.com/external-secrets/external-secrets/apis/externalsecrets/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// publishes an in-cluster Secret back to a secret store - the source is either any Secret in the namespace
+// (e.g. a cert-manager TLS secret) or the credentials the DB operator created for a `db.users` entry
+type PushSecret struct {
+	SecretStore es.SecretStoreRef `json:"secretStore" validate:"required"`
+	// path of the secret in the store
+	RemotePath string `json:"remotePath" validate:"required"`
+
+	Secret string `json:"secret,omitempty" validate:"required_without=DBUser,excluded_with=DBUser"`
+	DBUser string `json:"dbUser,omitempty" validate:"required_without=Secret"`
+
+	// OPTIONAL - secret key => remote property (same as the key when empty). The whole Secret is pushed when not
+	// specified, except for `dbUser` which defaults to `username` and `password`
+	Keys map[string]*string `json:"keys,omitempty"`
+
+	// OPTIONAL - defaults to 1 minute
+	RefreshInterval *metav1.Duration `json:"refreshInterval,omitempty"`
+	// OPTIONAL - `Replace` (default) or `IfNotExists`
+	UpdatePolicy *esv1alpha1.PushSecretUpdatePolicy `json:"updatePolicy,omitempty" validate:"omitempty,oneof=Replace IfNotExists"`
+	// OPTIONAL - `None` (default) or `Delete` - whether the remote secret is deleted along with the PushSecret
+	DeletionPolicy *esv1alpha1.PushSecretDeletionPolicy `json:"deletionPolicy,omitempty" validate:"omitempty,oneof=Delete None"`
+}
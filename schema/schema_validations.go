@@ -1,6 +1,15 @@
 package schema
 
-import "fmt"
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
 
 func CustomValidations(values InputValues) error {
 	// here will be any arbitrary custom validations that are difficult or impossible to express otherwise
@@ -19,6 +28,36 @@ func CustomValidations(values InputValues) error {
 	if err := validateExclusiveHttpRoutes(values); err != nil {
 		return err
 	}
+
+	// 4. built-in alerts need the objects they alert on, and durations Prometheus understands
+	if err := validateBuiltinAlerts(values); err != nil {
+		return err
+	}
+
+	// 5. SLO names have to be unique, windows valid Prometheus durations
+	if err := validateSLOs(values); err != nil {
+		return err
+	}
+
+	// 6. auto-instrumentation can only target the main workload's containers
+	if err := validateOtelInstrumentation(values); err != nil {
+		return err
+	}
+
+	// 7. PushSecrets of DB credentials need the user to exist
+	if err := validatePushSecretDBUsers(values); err != nil {
+		return err
+	}
+
+	// 8. explicit port names become container port names, which are limited to 15 characters
+	if err := validatePortNames(values); err != nil {
+		return err
+	}
+
+	// 9. global labels and annotations end up on every object, better to fail early than on the first object
+	if err := validateGlobalMetadata(values); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -35,8 +74,8 @@ func validateNodePortRange(values InputValues) error {
 			return fmt.Errorf("node port on port %d must be between 30000 and 32767", port.Port)
 		}
 	}
-	for name, sidecar := range values.Sidecars {
-		for _, port := range sidecar.Ports {
+	for _, name := range slices.Sorted(maps.Keys(values.Sidecars)) {
+		for _, port := range values.Sidecars[name].Ports {
 			if port.NodePort != nil && (*port.NodePort < 30000 || *port.NodePort > 32767) {
 				return fmt.Errorf("node port on port %d of sidecar %s must be between 30000 and 32767", port.Port, name)
 			}
@@ -51,3 +90,160 @@ func validateExclusiveHttpRoutes(values InputValues) error {
 	}
 	return nil
 }
+
+func validateBuiltinAlerts(values InputValues) error {
+	if values.PrometheusRules == nil || values.PrometheusRules.Alerts == nil {
+		return nil
+	}
+	alerts := values.PrometheusRules.Alerts
+	if alerts.HPAMaxedOut != nil && values.Autoscaling == nil {
+		return fmt.Errorf("prometheusRules.alerts.hpaMaxedOut requires `autoscaling`")
+	}
+	if (alerts.CronjobFailed != nil || alerts.CronjobNotScheduled != nil) && len(values.Cronjobs) == 0 {
+		return fmt.Errorf("prometheusRules.alerts.cronjobFailed/cronjobNotScheduled require at least one cronjob")
+	}
+	if alerts.PreDeploymentJobFailed != nil && values.PreDeploymentJob == nil {
+		return fmt.Errorf("prometheusRules.alerts.preDeploymentJobFailed requires `preDeploymentJob`")
+	}
+	if alerts.PVCUsage != nil && !hasChartPVCs(values) {
+		return fmt.Errorf("prometheusRules.alerts.pvcUsage requires a new `persistent` volume or `statefulSetSpec.volumeClaimTemplates`")
+	}
+	if alerts.CronjobNotScheduled != nil {
+		if _, err := model.ParseDuration(string(alerts.CronjobNotScheduled.Within)); err != nil {
+			return fmt.Errorf("prometheusRules.alerts.cronjobNotScheduled.within: %v", err)
+		}
+	}
+	return nil
+}
+
+// PVCs the chart creates, or the StatefulSet's claims
+func hasChartPVCs(values InputValues) bool {
+	volumes := []map[string]Volume{values.Volumes}
+	if values.PreDeploymentJob != nil {
+		volumes = append(volumes, values.PreDeploymentJob.Volumes)
+	}
+	for _, cronjob := range values.Cronjobs {
+		volumes = append(volumes, cronjob.Volumes)
+	}
+	for _, podVolumes := range volumes {
+		for _, volume := range podVolumes {
+			if persistent, ok := volume.Variant.(PersistentVolume); ok && !*persistent.Existing {
+				return true
+			}
+		}
+	}
+	isStatefulSet := values.Kind != nil && *values.Kind == "StatefulSet"
+	return isStatefulSet && values.StatefulSetSpec != nil && len(values.StatefulSetSpec.VolumeClaimTemplates) > 0
+}
+
+func validateSLOs(values InputValues) error {
+	names := map[string]bool{}
+	for _, slo := range values.SLOs {
+		if names[slo.Name] {
+			return fmt.Errorf("duplicate SLO name %q", slo.Name)
+		}
+		names[slo.Name] = true
+		if slo.Window != nil {
+			window, err := model.ParseDuration(string(*slo.Window))
+			if err != nil {
+				return fmt.Errorf("SLO %q window: %v", slo.Name, err)
+			}
+			// the slowest burn rate alert looks 3 days back
+			if time.Duration(window) < 3*24*time.Hour {
+				return fmt.Errorf("SLO %q window must be at least 3d", slo.Name)
+			}
+		}
+	}
+	return nil
+}
+
+func validateOtelInstrumentation(values InputValues) error {
+	if values.Otel == nil {
+		return nil
+	}
+	containers := map[string]bool{"main": true}
+	if values.MainContainerName != nil {
+		containers = map[string]bool{*values.MainContainerName: true}
+	}
+	for name := range values.Sidecars {
+		containers[name] = true
+	}
+	for _, name := range slices.Sorted(maps.Keys(values.Otel.Instrumentation)) {
+		if !containers[name] {
+			return fmt.Errorf("otel.instrumentation: container %q not found", name)
+		}
+	}
+	return nil
+}
+
+func validatePushSecretDBUsers(values InputValues) error {
+	for _, name := range slices.Sorted(maps.Keys(values.PushSecrets)) {
+		push := values.PushSecrets[name]
+		if push.DBUser == "" {
+			continue
+		}
+		if values.DB == nil || !*values.DB.Enabled {
+			return fmt.Errorf("pushSecrets.%s: `dbUser` requires `db` to be enabled", name)
+		}
+		if _, ok := values.DB.Users[push.DBUser]; !ok {
+			return fmt.Errorf("pushSecrets.%s: user %q not found in `db.users`", name, push.DBUser)
+		}
+	}
+	return nil
+}
+
+func validatePortNames(values InputValues) error {
+	validate := func(owner string, ports []Port) error {
+		for _, port := range ports {
+			if port.Name == nil {
+				continue
+			}
+			if errs := validation.IsValidPortName(*port.Name); len(errs) > 0 {
+				return fmt.Errorf("invalid name '%s' of port %d of %s: %s", *port.Name, port.Port, owner, strings.Join(errs, ", "))
+			}
+		}
+		return nil
+	}
+
+	if err := validate("the main container", values.Ports); err != nil {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(values.Sidecars)) {
+		if err := validate(fmt.Sprintf("sidecar %s", name), values.Sidecars[name].Ports); err != nil {
+			return err
+		}
+	}
+	for _, container := range values.InitContainers {
+		if err := validate(fmt.Sprintf("init container %s", container.Name), container.Ports); err != nil {
+			return err
+		}
+	}
+	if values.PreDeploymentJob != nil {
+		if err := validate("the pre-deployment job", values.PreDeploymentJob.Ports); err != nil {
+			return err
+		}
+	}
+	for _, cronjob := range values.Cronjobs {
+		if err := validate(fmt.Sprintf("cronjob %s", cronjob.Name), cronjob.Ports); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateGlobalMetadata(values InputValues) error {
+	for _, key := range slices.Sorted(maps.Keys(values.GlobalLabels)) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid globalLabels key '%s': %s", key, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(values.GlobalLabels[key]); len(errs) > 0 {
+			return fmt.Errorf("invalid value of globalLabels key '%s': %s", key, strings.Join(errs, ", "))
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(values.GlobalAnnotations)) {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("invalid globalAnnotations key '%s': %s", key, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
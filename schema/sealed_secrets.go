@@ -0,0 +1,24 @@
+package schema
+
+import corev1 "k8s.io/api/core/v1"
+
+// an already sealed (`kubeseal --raw`) Secret, rendered as a `bitnami.com/v1alpha1` SealedSecret
+// named `{service}--{component}--{env}--sealed--{name}` - with the default `strict` scope, that's the name to seal it for
+type SealedSecretDefinition struct {
+	Name string `json:"name" validate:"required"`
+	// OPTIONAL - `strict` (default), `namespace-wide` or `cluster-wide`
+	Scope string `json:"scope,omitempty" validate:"omitempty,oneof=strict namespace-wide cluster-wide"`
+	// key => sealed value
+	EncryptedData map[string]string `json:"encryptedData" validate:"required,min=1"`
+	// OPTIONAL - same as a `kubeSecrets` value - env name => secret key, the whole Secret is mounted through `envFrom` when not specified
+	Mapping SecretMapping `json:"mapping,omitempty"`
+	// OPTIONAL - type and metadata of the unsealed Secret
+	Template *SealedSecretTemplate `json:"template,omitempty"`
+}
+
+type SealedSecretTemplate struct {
+	// OPTIONAL - defaults to `Opaque`
+	Type        corev1.SecretType `json:"type,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
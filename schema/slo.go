@@ -0,0 +1,50 @@
+package schema
+
+import "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+
+type SLO struct {
+	// used in the `slo` label of the generated series and alerts
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description,omitempty"`
+	// target in percent, e.g. `99.9`
+	Objective float64 `json:"objective" validate:"required,gt=0,lt=100"`
+	// OPTIONAL - SLO period, defaults to `30d`
+	Window *v1.Duration `json:"window,omitempty"`
+
+	SLI      SLI          `json:"sli"`
+	Alerting *SLOAlerting `json:"alerting,omitempty"`
+}
+
+// exactly one of `events` or `latency`
+type SLI struct {
+	Events  *SLIEvents  `json:"events,omitempty" validate:"required_without=Latency,excluded_with=Latency"`
+	Latency *SLILatency `json:"latency,omitempty"`
+}
+
+// PromQL queries, `{{.window}}` is replaced with the rate window and `{{.selector}}` with the label matchers of the
+// chart's Service (`namespace="{namespace}", service="{service}--{component}--{env}"`)
+type SLIEvents struct {
+	GoodQuery  string `json:"goodQuery" validate:"required"`
+	TotalQuery string `json:"totalQuery" validate:"required"`
+}
+
+// requests faster than `threshold` are good, read off a Prometheus histogram
+type SLILatency struct {
+	// histogram name without the `_bucket`/`_count` suffix, e.g. `http_request_duration_seconds`
+	Metric string `json:"metric" validate:"required"`
+	// bucket boundary (`le`) in the histogram's unit, has to match an existing bucket exactly, e.g. `0.5`
+	Threshold string `json:"threshold" validate:"required"`
+	// OPTIONAL - label matchers, defaults to the chart's Service selector (see `SLIEvents`)
+	Selector *string `json:"selector,omitempty"`
+}
+
+type SLOAlerting struct {
+	// OPTIONAL - only the recording rules are rendered
+	Disabled bool `json:"disabled,omitempty"`
+	// OPTIONAL - severity of the fast burn alert, defaults to `critical`
+	PageSeverity string `json:"pageSeverity,omitempty"`
+	// OPTIONAL - severity of the slow burn alert, defaults to `warning`
+	TicketSeverity string            `json:"ticketSeverity,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+	Annotations    map[string]string `json:"annotations,omitempty"`
+}
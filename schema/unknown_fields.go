@@ -0,0 +1,52 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+// UnknownFields returns the paths of the fields in the values which aren't part of the values schema - the YAML parser
+// silently drops them, so a typo in a field name would go unnoticed otherwise. Kubernetes types are passed through as
+// they are, their fields aren't checked
+func UnknownFields(values map[string]interface{}) []string {
+	unknown := unknownFields(OpenAPISchema(), values, "")
+	slices.Sort(unknown)
+	return unknown
+}
+
+func unknownFields(s apiextensionsv1.JSONSchemaProps, value interface{}, path string) []string {
+	unknown := []string{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			fieldPath := key
+			if path != "" {
+				fieldPath = path + "." + key
+			}
+			unknown = append(unknown, unknownField(s, key, field, fieldPath)...)
+		}
+	case []interface{}:
+		if s.Items == nil || s.Items.Schema == nil {
+			return unknown
+		}
+		for i, item := range v {
+			unknown = append(unknown, unknownFields(*s.Items.Schema, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+	}
+	return unknown
+}
+
+func unknownField(s apiextensionsv1.JSONSchemaProps, key string, value interface{}, path string) []string {
+	if prop, ok := s.Properties[key]; ok {
+		return unknownFields(prop, value, path)
+	}
+	if s.AdditionalProperties != nil && s.AdditionalProperties.Schema != nil {
+		return unknownFields(*s.AdditionalProperties.Schema, value, path)
+	}
+	if (s.XPreserveUnknownFields != nil && *s.XPreserveUnknownFields) || s.AdditionalProperties != nil || s.Type != "object" {
+		return nil
+	}
+	return []string{path}
+}
@@ -125,9 +125,11 @@ func (SecretVolume) IsVolumeVariant() {}
 
 type ConfigMapVolume struct {
 	// type: `configMap`
-	Mode          *int32             `json:"mode"`
-	ConfigMapName string             `json:"configMapName" validate:"required"`
-	Items         map[string]*string `json:"items,omitempty"`
+	Mode          *int32 `json:"mode"`
+	ConfigMapName string `json:"configMapName,omitempty" validate:"required_without=ConfigMap,excluded_with=ConfigMap"`
+	// shorthand for one of the chart's own `configMaps` - its key instead of the generated `{service}--{component}--{env}-{name}`
+	ConfigMap string             `json:"configMap,omitempty" validate:"required_without=ConfigMapName"`
+	Items     map[string]*string `json:"items,omitempty"`
 }
 
 func (ConfigMapVolume) IsVolumeVariant() {}